@@ -3,6 +3,9 @@ package common
 const (
 	SANDBOX_API_BASE_URL      = "https://preprod-api.myinvois.hasil.gov.my"
 	SANDBOX_IDENTITY_BASE_URL = "https://preprod-api.myinvois.hasil.gov.my"
+
+	PRODUCTION_API_BASE_URL      = "https://api.myinvois.hasil.gov.my"
+	PRODUCTION_IDENTITY_BASE_URL = "https://api.myinvois.hasil.gov.my"
 )
 
 // Reference: https://sdk.myinvois.hasil.gov.my/standard-error-response/
@@ -0,0 +1,23 @@
+package common
+
+// Logger receives the diagnostics of this module. Its methods take a
+// message followed by alternating keys and values, like log/slog, so that a
+// *slog.Logger can be passed in as is:
+//
+//	api := platform.NewApi(clientId, clientSecret, platform.WithLogger(slog.Default()))
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NopLogger discards everything. It is the default logger.
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
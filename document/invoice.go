@@ -1,9 +1,11 @@
 package document
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Rhymond/go-money"
+	"github.com/programmer-my/einvoice-go/common"
 	"github.com/programmer-my/einvoice-go/ubl"
 )
 
@@ -71,7 +73,7 @@ type InvoiceDocument struct {
 	Code                 string
 	Date                 string
 	Time                 string // hh:mm:ss
-	Signature            string // TODO
+	Signature            string // Deprecated: unused, sign the built invoice with signature.SignInvoice
 	CurrencyCode         money.Currency
 	CurrencyExchangeRate string // only required if non-MYR
 	// BillingFrequency string /* Daily, Weekly, Biweekly, Monthly, Bimonthly, Quarterly, Half-yearly, Yearly, Others / Not Applicable */
@@ -101,10 +103,52 @@ type InvoiceDocument struct {
 	TaxType                  string // https://sdk.myinvois.hasil.gov.my/codes/tax-types/
 }
 
+type builder struct {
+	logger common.Logger
+
+	ctx             context.Context
+	versionResolver VersionResolver
+	fallbackVersion string
+}
+
+type BuilderOption func(*builder)
+
+// Report calculation errors to logger. They are discarded by default.
+func WithLogger(logger common.Logger) BuilderOption {
+	return func(b *builder) {
+		b.logger = logger
+	}
+}
+
+// Resolve the document type version with resolver when doc.Version is
+// empty, e.g. with a platform.DocumentTypeCatalog. ctx bounds the lookup.
+// If resolution fails, the error is reported to the logger and the invoice
+// declares fallback, e.g. "1.1", instead. Call ResolveVersion beforehand to
+// handle errors yourself.
+func WithVersionResolver(ctx context.Context, resolver VersionResolver, fallback string) BuilderOption {
+	return func(b *builder) {
+		b.ctx = ctx
+		b.versionResolver = resolver
+		b.fallbackVersion = fallback
+	}
+}
+
 // Perform mapping of core data structures into UBL Invoice
 //
 // Reference: https://sdk.myinvois.hasil.gov.my/documents/invoice-v1-1/
-func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
+func UblInvoiceBuilder(doc InvoiceDocument, opts ...BuilderOption) *ubl.UBL_Invoice {
+	b := builder{logger: common.NopLogger}
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	if b.versionResolver != nil {
+		if err := ResolveVersion(b.ctx, &doc, b.versionResolver); err != nil {
+			b.logger.Error("failed to resolve document type version", "invoice", doc.Code, "fallback", b.fallbackVersion, "err", err)
+			doc.Version = b.fallbackVersion
+		}
+	}
+
 	supplier := doc.Supplier
 	buyer := doc.Buyer
 
@@ -112,12 +156,14 @@ func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
 	inv := ubl.NewInvoice()
 	inv.DocumentCurrencyCode = doc.CurrencyCode.Code
 	inv.Currency = doc.CurrencyCode
-	inv.InvoiceTypeCode = doc.TypeCode
+	inv.InvoiceTypeCode = ubl.CBC_InvoiceTypeCode{
+		Value:         doc.TypeCode,
+		ListVersionID: doc.Version,
+	}
 	inv.ID = doc.Code
 	inv.IssueDate = doc.Date
 	inv.IssueTime = &doc.Time
-	// TODO: add Signature field to InvoiceDocument
-	// inv.Signature = ""
+	// v1.1 documents are signed afterwards, see signature.SignInvoice
 	// TODO: refer comments (conflict in spec)
 	inv.TaxExchangeRate = &ubl.CAC_TaxExchangeRate{
 		CalculationRate: doc.CurrencyExchangeRate,
@@ -241,7 +287,7 @@ func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
 		// 	continue
 		// }
 		if leaNew, err := lmt_LineExtensionAmount.Add(money.New(line.LineExtensionAmount.Value, line.LineExtensionAmount.CurrencyID.Code)); err != nil {
-			fmt.Printf("error when calculating lineextensionamount: %s", err)
+			b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "LineExtensionAmount", "err", err)
 		} else {
 			lmt_LineExtensionAmount = leaNew
 		}
@@ -260,13 +306,13 @@ func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
 
 		if charge.ChargeIndicator {
 			if ata, err := lmt_AllowanceTotalAmount.Add(amount); err != nil {
-				fmt.Printf("error when calculating allowancetotalamount: %s", err)
+				b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "AllowanceTotalAmount", "err", err)
 			} else {
 				lmt_AllowanceTotalAmount = ata
 			}
 		} else {
 			if cta, err := lmt_ChargeTotalAmount.Add(amount); err != nil {
-				fmt.Printf("error when calculating chargetotalamount: %s", err)
+				b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "ChargeTotalAmount", "err", err)
 			} else {
 				lmt_ChargeTotalAmount = cta
 			}
@@ -275,11 +321,11 @@ func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
 
 	// MAKE SURE ALL OF THESE VARIABLES HAVE BEEN CALCULATED
 	if tea, err := lmt_LineExtensionAmount.Subtract(money.New(lmt_AllowanceTotalAmount.Amount(), lmt_AllowanceTotalAmount.Currency().Code)); err != nil {
-		fmt.Printf("error when calculating lmt_TaxExclusiveAmount - lmt_AllowanceTotalAmount: %s", err)
+		b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "TaxExclusiveAmount", "err", err)
 	} else {
 		lmt_TaxExclusiveAmount = tea
 		if tea, err := lmt_TaxExclusiveAmount.Subtract(money.New(lmt_ChargeTotalAmount.Amount(), lmt_ChargeTotalAmount.Currency().Code)); err != nil {
-			fmt.Printf("error when calculating lmt_TaxExclusiveAmount - lmt_ChargeTotalAmount: %s", err)
+			b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "TaxExclusiveAmount", "err", err)
 		} else {
 			lmt_TaxExclusiveAmount = tea
 		}
@@ -296,20 +342,20 @@ func UblInvoiceBuilder(doc InvoiceDocument) *ubl.UBL_Invoice {
 	// lmt_TaxInclusiveAmount = lmt_TaxExclusiveAmount + taxTotalAmount
 
 	if tia, err := lmt_TaxExclusiveAmount.Add(money.New(inv.TaxTotal.TaxAmount.Value, inv.TaxTotal.TaxAmount.CurrencyID.Code)); err != nil {
-		fmt.Printf("error when calculating lmt_TaxExclusiveAmount + inv.TaxTotal.TaxAmount.Value: %s", err)
+		b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "TaxInclusiveAmount", "err", err)
 	} else {
 		lmt_TaxInclusiveAmount = tia
 	}
 
 	// lmt_PayableAmount = lmt_TaxInclusiveAmount - lmt_PrepaidAmount + lmt_PayableRoundingAmount
 	if pa, err := lmt_TaxInclusiveAmount.Subtract(lmt_PrepaidAmount); err != nil {
-		fmt.Printf("error when calculating lmt_TaxInclusiveAmount - lmt_PrepaidAmount: %s", err)
+		b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "PayableAmount", "err", err)
 	} else {
 		lmt_PayableAmount = pa
 	}
 
 	if pa, err := lmt_PayableAmount.Add(lmt_PayableRoundingAmount); err != nil {
-		fmt.Printf("error when calculating lmt_PayableAmount + lmt_PayableRoundingAmount: %s", err)
+		b.logger.Error("failed to calculate legal monetary total", "invoice", doc.Code, "amount", "PayableAmount", "err", err)
 	} else {
 		lmt_PayableAmount = pa
 	}
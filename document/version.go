@@ -0,0 +1,59 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Resolves the document type version, e.g. "1.1", that a document with the
+// given invoice type code, e.g. "01", issued at issuedAt must declare.
+//
+// platform.DocumentTypeCatalog is the canonical implementation.
+type VersionResolver interface {
+	ResolveVersion(ctx context.Context, invoiceTypeCode string, issuedAt time.Time) (string, error)
+}
+
+// Layouts of the issue date and time. The platform requires the time in UTC,
+// written as "15:04:05Z", but an offset or no zone at all, meaning UTC, is
+// accepted too.
+var issuedAtLayouts = []string{
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// The issue date and time of the document, in UTC.
+func (doc *InvoiceDocument) IssuedAt() (time.Time, error) {
+	value := doc.Date + " " + doc.Time
+
+	for _, layout := range issuedAtLayouts {
+		if issuedAt, err := time.Parse(layout, value); err == nil {
+			return issuedAt.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid issue date %q and time %q", doc.Date, doc.Time)
+}
+
+// Fill in doc.Version with the version active at the issue date of the
+// document, unless it is already set. UblInvoiceBuilder does so itself when
+// given WithVersionResolver; call it beforehand to handle errors.
+func ResolveVersion(ctx context.Context, doc *InvoiceDocument, resolver VersionResolver) error {
+	if doc.Version != "" {
+		return nil
+	}
+
+	issuedAt, err := doc.IssuedAt()
+	if err != nil {
+		return err
+	}
+
+	version, err := resolver.ResolveVersion(ctx, doc.TypeCode, issuedAt)
+	if err != nil {
+		return err
+	}
+
+	doc.Version = version
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package document_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/programmer-my/einvoice-go/document"
+)
+
+// stubResolver resolves every document to version, recording the requests.
+type stubResolver struct {
+	version  string
+	err      error
+	issuedAt []time.Time
+}
+
+func (r *stubResolver) ResolveVersion(ctx context.Context, invoiceTypeCode string, issuedAt time.Time) (string, error) {
+	r.issuedAt = append(r.issuedAt, issuedAt)
+	return r.version, r.err
+}
+
+func newTestDocument(issueTime string) document.InvoiceDocument {
+	return document.InvoiceDocument{
+		TypeCode:           "01",
+		Code:               "INV-001",
+		Date:               "2024-07-23",
+		Time:               issueTime,
+		CurrencyCode:       *money.GetCurrency(money.MYR),
+		TotalExcludingTax:  *money.New(0, money.MYR),
+		TotalIncludingTax:  *money.New(0, money.MYR),
+		TotalPayableAmount: *money.New(0, money.MYR),
+		TotalTaxAmount:     *money.New(0, money.MYR),
+	}
+}
+
+func TestIssuedAt(t *testing.T) {
+	expected := time.Date(2024, 7, 23, 15, 14, 54, 0, time.UTC)
+
+	for _, issueTime := range []string{"15:14:54Z", "23:14:54+08:00", "15:14:54"} {
+		doc := newTestDocument(issueTime)
+
+		issuedAt, err := doc.IssuedAt()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", issueTime, err)
+			continue
+		}
+
+		if !issuedAt.Equal(expected) || issuedAt.Location() != time.UTC {
+			t.Errorf("%s: expected issue time to be %s, got %s", issueTime, expected, issuedAt)
+		}
+	}
+
+	doc := newTestDocument("3pm")
+	if _, err := doc.IssuedAt(); err == nil {
+		t.Errorf("expected error for invalid issue time")
+	}
+}
+
+func TestBuilderResolvesVersion(t *testing.T) {
+	resolver := stubResolver{version: "1.1"}
+
+	inv := document.UblInvoiceBuilder(newTestDocument("15:14:54Z"), document.WithVersionResolver(context.Background(), &resolver, "1.0"))
+
+	if inv.InvoiceTypeCode.ListVersionID != "1.1" {
+		t.Errorf("expected version to be 1.1, got %s", inv.InvoiceTypeCode.ListVersionID)
+	}
+
+	if len(resolver.issuedAt) != 1 || !resolver.issuedAt[0].Equal(time.Date(2024, 7, 23, 15, 14, 54, 0, time.UTC)) {
+		t.Errorf("expected the version to be resolved at the issue time, got %v", resolver.issuedAt)
+	}
+
+	// an explicit version is kept
+	doc := newTestDocument("15:14:54Z")
+	doc.Version = "1.0"
+
+	inv = document.UblInvoiceBuilder(doc, document.WithVersionResolver(context.Background(), &resolver, "1.1"))
+
+	if inv.InvoiceTypeCode.ListVersionID != "1.0" || len(resolver.issuedAt) != 1 {
+		t.Errorf("expected version 1.0 to be kept without resolving, got %s", inv.InvoiceTypeCode.ListVersionID)
+	}
+
+	// errors fall back to the given version
+	failing := stubResolver{err: errors.New("catalog unavailable")}
+
+	inv = document.UblInvoiceBuilder(newTestDocument("15:14:54Z"), document.WithVersionResolver(context.Background(), &failing, "1.1"))
+
+	if inv.InvoiceTypeCode.ListVersionID != "1.1" {
+		t.Errorf("expected the fallback version 1.1, got %s", inv.InvoiceTypeCode.ListVersionID)
+	}
+}
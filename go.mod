@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/Rhymond/go-money v1.0.13
 	github.com/go-playground/validator v9.31.0+incompatible
+	golang.org/x/crypto v0.14.0
 )
 
 require (
@@ -7,15 +7,21 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/programmer-my/einvoice-go/common"
+	"github.com/programmer-my/einvoice-go/ubl"
 )
 
 const TAXPAYER_LOGIN_ENDPOINT = "/connect/token"
@@ -79,24 +85,109 @@ type IntermLoginResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
+type Environment string
+
+const (
+	ENV_PREPROD    Environment = "preprod"
+	ENV_PRODUCTION Environment = "production"
+)
+
+var ErrUnknownEnvironment = errors.New("unknown environment")
+
 type Api struct {
-	clientId     string
-	clientSecret string
-	AccessToken  string
+	clientId        string
+	clientSecret    string
+	apiBaseUrl      string
+	identityBaseUrl string
+	tokens          *tokenSource
+	sessions        *sessionPool
+	httpClient      *http.Client
+	limiter         *rateLimiter
+	retryPolicy     RetryPolicy
+	onBehalfOf      string // TIN of the taxpayer an intermediary acts for, empty otherwise
+	auditHook       AuditHook
+	logger          common.Logger
+	metrics         Metrics
+	err             error // misconfiguration, returned by every call
+}
+
+type ApiOption func(*Api)
+
+// Point the client at the base URLs of a known environment.
+// The default is ENV_PREPROD. Any other value, including the empty one,
+// leaves the base URLs unchanged and makes every call fail with
+// ErrUnknownEnvironment, rather than silently picking an environment.
+func WithEnvironment(env Environment) ApiOption {
+	return func(a *Api) {
+		switch env {
+		case ENV_PREPROD:
+			a.apiBaseUrl = common.SANDBOX_API_BASE_URL
+			a.identityBaseUrl = common.SANDBOX_IDENTITY_BASE_URL
+		case ENV_PRODUCTION:
+			a.apiBaseUrl = common.PRODUCTION_API_BASE_URL
+			a.identityBaseUrl = common.PRODUCTION_IDENTITY_BASE_URL
+		default:
+			a.err = fmt.Errorf("%w %q", ErrUnknownEnvironment, env)
+		}
+	}
 }
 
-func NewApi(clientId string, clientSecret string) *Api {
-	return &Api{
-		clientId:     clientId,
-		clientSecret: clientSecret,
+// Override the base URL used for the e-Invoice API endpoints,
+// e.g. to point at a local stub.
+func WithApiBaseUrl(baseUrl string) ApiOption {
+	return func(a *Api) {
+		a.apiBaseUrl = strings.TrimRight(baseUrl, "/")
 	}
 }
 
-func (a *Api) DoTaxPayerLogin(req *TaxPayerLoginRequest) (interface{}, error) {
-	loginUrl, err := url.JoinPath(common.SANDBOX_IDENTITY_BASE_URL, TAXPAYER_LOGIN_ENDPOINT)
-	if err != nil {
-		return "", err
+// Override the base URL used for the identity (login) endpoints.
+func WithIdentityBaseUrl(baseUrl string) ApiOption {
+	return func(a *Api) {
+		a.identityBaseUrl = strings.TrimRight(baseUrl, "/")
 	}
+}
+
+// Use the given HTTP client for every call, e.g. to configure proxies,
+// mTLS, timeouts or a test transport. The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) ApiOption {
+	return func(a *Api) {
+		a.httpClient = client
+	}
+}
+
+func NewApi(clientId string, clientSecret string, opts ...ApiOption) *Api {
+	a := &Api{
+		clientId:        clientId,
+		clientSecret:    clientSecret,
+		apiBaseUrl:      common.SANDBOX_API_BASE_URL,
+		identityBaseUrl: common.SANDBOX_IDENTITY_BASE_URL,
+		sessions:        newSessionPool(),
+		httpClient:      http.DefaultClient,
+		limiter:         newRateLimiter(DefaultRateLimits),
+		retryPolicy:     DefaultRetryPolicy,
+		logger:          common.NopLogger,
+		metrics:         nopMetrics{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.tokens = newTokenSource(a.taxPayerToken)
+
+	return a
+}
+
+func (a *Api) apiUrl(endpoint string) string {
+	return a.apiBaseUrl + endpoint
+}
+
+func (a *Api) identityUrl(endpoint string) string {
+	return a.identityBaseUrl + endpoint
+}
+
+func (a *Api) DoTaxPayerLogin(ctx context.Context, req *TaxPayerLoginRequest) (*TaxPayerLoginResponse, error) {
+	loginUrl := a.identityUrl(TAXPAYER_LOGIN_ENDPOINT)
 
 	body := url.Values{}
 	body.Set("client_id", req.ClientID)
@@ -104,32 +195,21 @@ func (a *Api) DoTaxPayerLogin(req *TaxPayerLoginRequest) (interface{}, error) {
 	body.Set("grant_type", req.GrantType)
 	body.Set("scope", req.Scope)
 
-	httpReq, err := http.NewRequest(http.MethodPost, loginUrl, strings.NewReader(body.Encode()))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, loginUrl, strings.NewReader(body.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	httpReq.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.send(TAXPAYER_LOGIN_ENDPOINT, httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request to API failed: %s", err)
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 400 {
-		// { "statusCode": 400, "message": "Bad Request" }
-		// or {"error":"invalid_request"}
-		return nil, errors.New("bad request. please check your client id and client secret")
-	} else if resp.StatusCode == http.StatusOK {
+	if resp.StatusCode == http.StatusOK {
 		var retval TaxPayerLoginResponse
 
-		respBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-
 		err = json.Unmarshal(respBytes, &retval)
 		if err != nil {
 			return nil, err
@@ -138,14 +218,14 @@ func (a *Api) DoTaxPayerLogin(req *TaxPayerLoginRequest) (interface{}, error) {
 		return &retval, nil
 	}
 
-	return nil, fmt.Errorf("unexpected HTTP status code %d", resp.StatusCode)
+	// 400 means the client id or client secret is wrong, with either
+	// { "statusCode": 400, "message": "Bad Request" }
+	// or {"error":"invalid_request"}
+	return nil, newAPIError(resp, respBytes)
 }
 
-func (a *Api) DoIntemediarySystemLogin(req *IntermLoginRequest) (*IntermLoginResponse, error) {
-	endpointUrl, err := url.JoinPath(common.SANDBOX_IDENTITY_BASE_URL, INTERM_LOGIN_ENDPOINT)
-	if err != nil {
-		return nil, err
-	}
+func (a *Api) DoIntemediarySystemLogin(ctx context.Context, req *IntermLoginRequest) (*IntermLoginResponse, error) {
+	endpointUrl := a.identityUrl(INTERM_LOGIN_ENDPOINT)
 
 	httpBody := url.Values{}
 	httpBody.Set("client_id", req.ClientID)
@@ -153,7 +233,7 @@ func (a *Api) DoIntemediarySystemLogin(req *IntermLoginRequest) (*IntermLoginRes
 	httpBody.Set("grant_type", req.GrantType)
 	httpBody.Set("scope", req.Scope)
 
-	httpReq, err := http.NewRequest(http.MethodPost, endpointUrl, strings.NewReader(httpBody.Encode()))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointUrl, strings.NewReader(httpBody.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -161,49 +241,41 @@ func (a *Api) DoIntemediarySystemLogin(req *IntermLoginRequest) (*IntermLoginRes
 	httpReq.Header.Add("onbehalfof", req.OnBehalfOf)
 	httpReq.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.send(INTERM_LOGIN_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusBadRequest {
-		return nil, fmt.Errorf("bad request: please check your client ID and client secret")
-	} else if resp.StatusCode == http.StatusOK {
+	if resp.StatusCode == http.StatusOK {
 		var retval IntermLoginResponse
 
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-
-		if err := json.Unmarshal(b, &retval); err != nil {
+		if err := json.Unmarshal(respBytes, &retval); err != nil {
 			return nil, err
 		}
 
 		return &retval, nil
 	}
 
-	return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
+	// 400 means the client ID or client secret is wrong
+	return nil, newAPIError(resp, respBytes)
 }
 
 type DocumentTypeVersion struct {
-	Id            int     `json:"id"`
-	Name          string  `json:"name"`
-	Description   string  `json:"description"`
-	ActiveFrom    string  `json:"activeFrom"`    // TODO: timestamp
-	ActiveTo      string  `json:"activeTo"`      // TODO: timestamp
-	VersionNumber float32 `json:"versionNumber"` // the API returns a float for some reason
-	Status        string  `json:"status"`        // possible values: draft, published, deactivated
+	Id            int       `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	ActiveFrom    time.Time `json:"activeFrom"`
+	ActiveTo      time.Time `json:"activeTo"`      // zero if the version has no end date
+	VersionNumber float32   `json:"versionNumber"` // the API returns a float for some reason
+	Status        string    `json:"status"`        // possible values: draft, published, deactivated
 }
 
 type DocumentType struct {
 	Id              int                   `json:"id"`
 	InvoiceTypeCode int                   `json:"invoiceTypeCode"` // possible values: 1,2,3,4,11,12,13,14
 	Description     string                `json:"description"`
-	ActiveFrom      string                `json:"activeFrom"` // TODO: timestamp
-	ActiveTo        string                `json:"activeTo"`   // TODO: timestamp
+	ActiveFrom      time.Time             `json:"activeFrom"`
+	ActiveTo        time.Time             `json:"activeTo"` // zero if the document type has no end date
 	Versions        []DocumentTypeVersion `json:"documentTypeVersions"`
 }
 
@@ -212,32 +284,27 @@ type GetDocumentTypesResponse struct {
 	common.StandardErrResponse
 }
 
-func (a *Api) GetDocumentTypes() (*GetDocumentTypesResponse, error) {
+func (a *Api) GetDocumentTypes(ctx context.Context) (*GetDocumentTypesResponse, error) {
 
-	endpointUrl := common.SANDBOX_API_BASE_URL + "/api/v1.0/documenttypes"
+	endpointUrl := a.apiUrl(GET_DOCUMENT_TYPES_ENDPOINT)
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Add("Authorization", "Bearer "+a.AccessToken)
-
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(GET_DOCUMENT_TYPES_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
 	}
 
 	var retval GetDocumentTypesResponse
 
-	if err := json.Unmarshal(respBody, &retval); err != nil {
+	if err := json.Unmarshal(respBytes, &retval); err != nil {
 		return nil, err
 	}
 
@@ -245,11 +312,11 @@ func (a *Api) GetDocumentTypes() (*GetDocumentTypesResponse, error) {
 }
 
 type WorkflowParameter struct {
-	Id         int    `json:"id"`
-	Parameter  string `json:"parameter"`
-	Value      int    `json:"value"`
-	ActiveFrom string `json:"activeFrom"`         // TODO: timestamp
-	ActiveTo   string `json:"activeTo,omitempty"` // TODO: timestamp
+	Id         int       `json:"id"`
+	Parameter  string    `json:"parameter"`
+	Value      int       `json:"value"`
+	ActiveFrom time.Time `json:"activeFrom"`
+	ActiveTo   time.Time `json:"activeTo,omitempty"` // zero if the parameter has no end date
 }
 
 type GetDocumentTypeByIdResponse struct {
@@ -257,30 +324,22 @@ type GetDocumentTypeByIdResponse struct {
 	WorkflowParameters []WorkflowParameter
 }
 
-func (a *Api) GetDocumentTypeById(id string) (*GetDocumentTypeByIdResponse, error) {
-	endpointUrl := common.SANDBOX_API_BASE_URL + strings.Replace(GET_DOCUMENT_TYPE_BY_ID_ENDPOINT, "{id}", id, 1)
+func (a *Api) GetDocumentTypeById(ctx context.Context, id string) (*GetDocumentTypeByIdResponse, error) {
+	endpointUrl := a.apiUrl(strings.Replace(GET_DOCUMENT_TYPE_BY_ID_ENDPOINT, "{id}", url.PathEscape(id), 1))
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Add("Authorization", "Bearer "+a.AccessToken)
 	httpReq.Header.Add("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(GET_DOCUMENT_TYPE_BY_ID_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
 	if resp.StatusCode == http.StatusOK {
-		respBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-
 		var retval GetDocumentTypeByIdResponse
 
 		if err = json.Unmarshal(respBytes, &retval); err != nil {
@@ -288,11 +347,10 @@ func (a *Api) GetDocumentTypeById(id string) (*GetDocumentTypeByIdResponse, erro
 		}
 
 		return &retval, nil
-	} else if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("invalid id '%s'", id)
 	}
 
-	return nil, fmt.Errorf("unexpected HTTP status code %d", resp.StatusCode)
+	// 404 for an invalid id
+	return nil, newAPIError(resp, respBytes)
 }
 
 type GetDocumentTypeVersionResponse struct {
@@ -301,34 +359,26 @@ type GetDocumentTypeVersionResponse struct {
 	Description     string
 	VersionNumber   float32
 	Status          string // published, deactivated
-	ActiveFrom      string // TODO: timestamp
-	ActiveTo        string // TODO: timestamp
+	ActiveFrom      time.Time
+	ActiveTo        time.Time // zero if the version has no end date
 	JsonSchema      string
 	XmlSchema       string
 }
 
-func (a *Api) GetDocumentTypeVersion(id string, version string) (any, error) {
-	endpointUrl := common.SANDBOX_API_BASE_URL +
+func (a *Api) GetDocumentTypeVersion(ctx context.Context, id string, version string) (*GetDocumentTypeVersionResponse, error) {
+	endpointUrl := a.apiUrl(
 		strings.Replace(
-			strings.Replace(GET_DOCUMENT_TYPE_VERSION_ENDPOINT, "{id}", id, 1),
-			"{vid}", version, 1,
-		)
+			strings.Replace(GET_DOCUMENT_TYPE_VERSION_ENDPOINT, "{id}", url.PathEscape(id), 1),
+			"{vid}", url.PathEscape(version), 1,
+		),
+	)
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Add("Authorization", "Bearer "+a.AccessToken)
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	resp, respBytes, err := a.do(GET_DOCUMENT_TYPE_VERSION_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -336,22 +386,21 @@ func (a *Api) GetDocumentTypeVersion(id string, version string) (any, error) {
 	if resp.StatusCode == http.StatusOK {
 		var retval GetDocumentTypeVersionResponse
 
-		if err := json.Unmarshal(respBody, &retval); err != nil {
+		if err := json.Unmarshal(respBytes, &retval); err != nil {
 			return nil, err
 		}
 
 		return &retval, nil
-	} else if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("document type id '%s' with vid '%s' not found", id, version)
 	}
 
-	return nil, fmt.Errorf("unexpected HTTP status code %d", resp.StatusCode)
+	// 404 if there is no document type id with such vid
+	return nil, newAPIError(resp, respBytes)
 }
 
 type Notification struct {
 	Notificationid    string
-	ReceivedDateTime  string // TODO: timestamp
-	DeliveredDateTime string // TODO: timestamp, optional
+	ReceivedDateTime  string // see ReceivedAt
+	DeliveredDateTime string // optional, see DeliveredAt
 	TypeId            string
 	TypeName          string
 	FinalMessage      string // optional
@@ -369,8 +418,41 @@ type NotificationDeliveryAttempt struct {
 }
 
 type NotificationMetadata struct {
-	TotalPages int `json:"totalPages,string"`
-	TotalCount int `json:"totalCount,string"`
+	TotalPages int `json:"totalPages"`
+	TotalCount int `json:"totalCount"`
+}
+
+// The counts are sent either as numbers or as strings.
+func (m *NotificationMetadata) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		TotalPages json.Number `json:"totalPages"`
+		TotalCount json.Number `json:"totalCount"`
+	}
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		value json.Number
+		dst   *int
+	}{
+		{raw.TotalPages, &m.TotalPages},
+		{raw.TotalCount, &m.TotalCount},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		n, err := field.value.Int64()
+		if err != nil {
+			return err
+		}
+
+		*field.dst = int(n)
+	}
+
+	return nil
 }
 
 type GetNotificationsResponse struct {
@@ -378,88 +460,197 @@ type GetNotificationsResponse struct {
 	Metadata NotificationMetadata `json:"metadata"`
 }
 
-func (a *Api) GetNotifications(dateFrom string, dateTo string, notifType string,
-	language string, status string, channel string,
-	pageNo string, pageSize string) (any, error) {
+// Notification type IDs
+// Reference: https://sdk.myinvois.hasil.gov.my/einvoicingapi/03-get-notifications/
+const (
+	NOTIFICATION_PROFILE_DATA_VALIDATION      = "3"
+	NOTIFICATION_DOCUMENT_RECEIVED            = "6"
+	NOTIFICATION_DOCUMENT_VALIDATED           = "7"
+	NOTIFICATION_DOCUMENT_CANCELLED           = "8"
+	NOTIFICATION_USER_PROFILE_CHANGED         = "10"
+	NOTIFICATION_TAXPAYER_PROFILE_CHANGED     = "11"
+	NOTIFICATION_DOCUMENT_REJECTION_INITIATED = "15"
+	NOTIFICATION_ERP_DATA_VALIDATION          = "26"
+	NOTIFICATION_DOCUMENTS_PROCESSING_SUMMARY = "33"
+)
 
-	endpointUrl := common.SANDBOX_API_BASE_URL + GET_NOTIFICATIONS_ENDPOINT
+// Page size cannot exceed this value for GetNotifications.
+const GET_NOTIFICATIONS_MAX_PAGE_SIZE = 100
+
+type NotificationsQuery struct {
+	DateFrom *string // Optional: The start date and time of the notifications to retrieve, in UTC 	2022-11-25T01:59:10Z 	Optional
+	DateTo   *string // Optional: The end date and time of the notifications to retrieve, in UTC 	2022-12-22T23:59:59Z 	Optional
+	Type     *string // Optional: Notification type ID, see the NOTIFICATION_ constants 	6 	Optional
+	Language *string // Optional: Language of the notifications. Possible values: (ms, en) 	en 	Optional
+	Status   *string // Optional: Status of the notifications. Possible values: (new, pending, batched, delivered, error) 	delivered 	Optional
+	Channel  *string // Optional: Delivery channel of the notifications. Possible values: (email, push) 	email 	Optional
+	PageNo   int64   // Optional: number of the page to retrieve 	3 	Optional
+	PageSize int64   // Optional: number of the notifications to retrieve per page, at most 100 	20 	Optional
+}
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+// This API allows caller to get the notifications of the taxpayer,
+// one page at a time.
+//
+// Use NewNotificationsIterator to go through every page, or
+// NewNotificationConsumer to keep receiving new notifications.
+func (a *Api) GetNotifications(ctx context.Context, query *NotificationsQuery) (*GetNotificationsResponse, error) {
+	httpReq, err := buildGetNotificationsRequest(ctx, a.apiBaseUrl, query)
 	if err != nil {
 		return nil, err
 	}
 
-	queryParam := httpReq.URL.Query()
-	queryParam.Add("dateFrom", dateFrom)
-	queryParam.Add("dateTo", dateTo)
-	queryParam.Add("type", notifType)
-	queryParam.Add("language", language)
-	queryParam.Add("status", status)
-	queryParam.Add("channel", channel)
-	queryParam.Add("pageNo", pageNo)
-	queryParam.Add("pageSize", pageSize)
-
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(GET_NOTIFICATIONS_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
 
 	var retval GetNotificationsResponse
 
-	respBytes, err := io.ReadAll(resp.Body)
+	err = json.Unmarshal(respBytes, &retval)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(respBytes, &retval)
+	return &retval, nil
+}
+
+func buildGetNotificationsRequest(ctx context.Context, baseUrl string, query *NotificationsQuery) (*http.Request, error) {
+	endpointUrl := baseUrl + GET_NOTIFICATIONS_ENDPOINT
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &retval, nil
+	queryParam := httpReq.URL.Query()
+
+	if query.DateFrom != nil {
+		queryParam.Add("dateFrom", *query.DateFrom)
+	}
+
+	if query.DateTo != nil {
+		queryParam.Add("dateTo", *query.DateTo)
+	}
+
+	if query.Type != nil {
+		queryParam.Add("type", *query.Type)
+	}
+
+	if query.Language != nil {
+		queryParam.Add("language", *query.Language)
+	}
+
+	if query.Status != nil {
+		queryParam.Add("status", *query.Status)
+	}
+
+	if query.Channel != nil {
+		queryParam.Add("channel", *query.Channel)
+	}
+
+	if query.PageNo > 0 {
+		queryParam.Add("pageNo", fmt.Sprintf("%d", query.PageNo))
+	}
+
+	if query.PageSize > 0 {
+		queryParam.Add("pageSize", fmt.Sprintf("%d", query.PageSize))
+	}
+
+	httpReq.URL.RawQuery = queryParam.Encode()
+
+	return httpReq, nil
 }
 
-func (a *Api) ValidateTIN(tin string, idType TinIdType, idValue string) (bool, error) {
-	endpointUrl := common.SANDBOX_API_BASE_URL + strings.ReplaceAll(VALIDATE_TIN_ENDPOINT, "{tin}", tin)
+// This API allows caller to check whether a TIN is valid and matches the
+// given ID. A TIN that does not exist or does not match is reported as
+// false with no error.
+//
+// Use a TINValidator to validate many TINs with caching.
+func (a *Api) ValidateTIN(ctx context.Context, tin string, idType TinIdType, idValue string) (bool, error) {
+	endpointUrl := a.apiUrl(strings.ReplaceAll(VALIDATE_TIN_ENDPOINT, "{tin}", url.PathEscape(tin)))
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return false, err
 	}
 
-	httpReq.Header.Add("Authorization", "Bearer "+a.AccessToken)
-
 	query := httpReq.URL.Query()
 	query.Add("idType", string(idType))
 	query.Add("idValue", idValue)
 
 	httpReq.URL.RawQuery = query.Encode()
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(VALIDATE_TIN_ENDPOINT, httpReq)
 	if err != nil {
 		return false, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
 		return true, nil
-	} else if resp.StatusCode == http.StatusNotFound {
-		return false, fmt.Errorf("TIN %s not found", tin)
-	} else if resp.StatusCode == http.StatusBadRequest {
-		return false, fmt.Errorf("bad request")
+	case http.StatusNotFound:
+		// the TIN does not exist or does not match the ID
+		return false, nil
 	}
 
-	return false, fmt.Errorf("unexpected error code %d", resp.StatusCode)
+	// 400 for a malformed request
+	return false, newAPIError(resp, respBytes)
 }
 
+type DocumentFormat string
+
+const (
+	FORMAT_XML  DocumentFormat = "XML"
+	FORMAT_JSON DocumentFormat = "JSON"
+)
+
 type Document struct {
-	Format         string `json:"format"`   // TODO: valid: XML, JSON
-	Document       string `json:"document"` // in base64
-	DocumentSHA256 string `json:"documentHash"`
-	CodeNumber     string `json:"codeNumber"`
+	Format         DocumentFormat `json:"format"`
+	Document       string         `json:"document"`     // in base64
+	DocumentSHA256 string         `json:"documentHash"` // hex encoded SHA-256 of the raw document
+	CodeNumber     string         `json:"codeNumber"`   // internal document number, e.g. cbc:ID of the invoice
+}
+
+// NewDocument wraps a raw UBL document (XML or JSON) for submission.
+// The document is base64 encoded and its SHA-256 hash is computed
+// from the raw bytes, as required by the submission API.
+func NewDocument(format DocumentFormat, codeNumber string, raw []byte) Document {
+	hash := sha256.Sum256(raw)
+
+	return Document{
+		Format:         format,
+		Document:       base64.StdEncoding.EncodeToString(raw),
+		DocumentSHA256: hex.EncodeToString(hash[:]),
+		CodeNumber:     codeNumber,
+	}
+}
+
+// NewXMLDocument serializes a built UBL invoice into XML and wraps it for
+// submission. The invoice ID is used as the code number.
+func NewXMLDocument(inv *ubl.UBL_Invoice) (Document, error) {
+	b, err := xml.Marshal(inv)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to marshal invoice %s: %s", inv.ID, err)
+	}
+
+	raw := append([]byte(xml.Header), b...)
+
+	return NewDocument(FORMAT_XML, inv.ID, raw), nil
+}
+
+// NewJSONDocument serializes a built UBL invoice into UBL JSON and wraps it
+// for submission. The invoice ID is used as the code number.
+func NewJSONDocument(inv *ubl.UBL_Invoice) (Document, error) {
+	raw, err := ubl.MarshalInvoiceJSON(inv)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to marshal invoice %s: %w", inv.ID, err)
+	}
+
+	return NewDocument(FORMAT_JSON, inv.ID, raw), nil
 }
 
 type SubmitDocumentRequest struct { // max: 5MB
@@ -467,9 +658,9 @@ type SubmitDocumentRequest struct { // max: 5MB
 }
 
 type SubmitDocumentResponse struct {
-	SubmissionUID     string
-	AcceptedDocuments []AcceptedDocuments
-	RejectedDocuments []RejectedDocuments
+	SubmissionUID     string              `json:"submissionUid"`
+	AcceptedDocuments []AcceptedDocuments `json:"acceptedDocuments"`
+	RejectedDocuments []RejectedDocuments `json:"rejectedDocuments"`
 	common.StandardErrResponse
 }
 
@@ -479,47 +670,63 @@ type AcceptedDocuments struct {
 }
 
 type RejectedDocuments struct {
-	InvoiceCodeNumber string `json:"invoiceCodeNumber"`
-	Error             common.ErrResponse
+	InvoiceCodeNumber string             `json:"invoiceCodeNumber"`
+	Error             common.ErrResponse `json:"error"`
 }
 
-func (a *Api) SubmitDocument() (any, error) {
-	endpointUrl := common.SANDBOX_API_BASE_URL + SUBMIT_DOCUMENT_ENDPOINT
+// Submit one or more documents to be validated by the platform.
+//
+// A 202 response only means the submission was accepted for processing.
+// Individual documents may still be rejected, see RejectedDocuments.
+func (a *Api) SubmitDocument(ctx context.Context, docs []Document) (*SubmitDocumentResponse, error) {
+	if len(docs) == 0 {
+		return nil, errors.New("no documents to submit")
+	}
+
+	endpointUrl := a.apiUrl(SUBMIT_DOCUMENT_ENDPOINT)
 
-	var httpBody SubmitDocumentRequest // TODO: fill struct
+	httpBody := SubmitDocumentRequest{
+		Documents: docs,
+	}
 
 	bodyBytes, err := json.Marshal(httpBody)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpointUrl, bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointUrl, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, respBytes, err := a.do(SUBMIT_DOCUMENT_ENDPOINT, req)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
 	var retval SubmitDocumentResponse
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(respBytes, &retval); err != nil {
+			return nil, err
+		}
 
-	err = json.Unmarshal(respBytes, &retval)
-	if err != nil {
-		return nil, err
+		for _, rejected := range retval.RejectedDocuments {
+			a.logger.Warn("document rejected", "submissionUid", retval.SubmissionUID, "codeNumber", rejected.InvoiceCodeNumber,
+				"errorCode", rejected.Error.ErrorCode, "error", rejected.Error.ErrorMessage)
+		}
+
+		a.metrics.ObserveDocuments(DOCUMENT_ACCEPTED, len(retval.AcceptedDocuments))
+		a.metrics.ObserveDocuments(DOCUMENT_REJECTED, len(retval.RejectedDocuments))
+
+		return &retval, nil
 	}
 
-	return &retval, nil
+	// 400 or 422 if the whole submission is rejected, e.g. duplicates
+	return nil, newAPIError(resp, respBytes)
 }
 
 type CancelDocumentRequest struct {
@@ -533,8 +740,14 @@ type CancelDocumentResponse struct {
 	Error  common.ErrResponse `json:"error"`
 }
 
-func (a *Api) CancelDocument(docUuid string, reason string) (*CancelDocumentResponse, error) {
-	endpointUrl := strings.ReplaceAll(CANCEL_DOCUMENT_ENDPOINT, "{UUID}", docUuid)
+// This API allows the issuer to cancel a valid document within 72 hours of
+// its validation. Pass WithPrecheck() to verify this before calling the API.
+func (a *Api) CancelDocument(ctx context.Context, docUuid string, reason string, opts ...StateChangeOption) (*CancelDocumentResponse, error) {
+	if err := a.checkStateChange(ctx, docUuid, reason, "cancel", opts); err != nil {
+		return nil, err
+	}
+
+	endpointUrl := a.apiUrl(strings.ReplaceAll(CANCEL_DOCUMENT_ENDPOINT, "{UUID}", url.PathEscape(docUuid)))
 
 	reqBody := CancelDocumentRequest{
 		DesiredStatus: "cancelled",
@@ -546,7 +759,7 @@ func (a *Api) CancelDocument(docUuid string, reason string) (*CancelDocumentResp
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPut, endpointUrl, bytes.NewBuffer(b))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, endpointUrl, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
@@ -554,31 +767,22 @@ func (a *Api) CancelDocument(docUuid string, reason string) (*CancelDocumentResp
 	httpReq.Header.Add("Content-Type", "application/json")
 	httpReq.Header.Add("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(CANCEL_DOCUMENT_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
 
 	var retval CancelDocumentResponse
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	if err := json.Unmarshal(respBytes, &retval); err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		// TODO: handle success
-		return &retval, nil
-	} else {
-		// TODO: handle error
-		return &retval, nil
-	}
+	return &retval, nil
 }
 
 type RejectDocumentRequest struct {
@@ -592,8 +796,15 @@ type RejectDocumentResponse struct {
 	Error  common.ErrResponse `json:"error"`
 }
 
-func (a *Api) RejectDocument(docUuid string, reason string) (*RejectDocumentResponse, error) {
-	endpointUrl := strings.ReplaceAll(CANCEL_DOCUMENT_ENDPOINT, "{UUID}", docUuid)
+// This API allows the receiver to request the rejection of a valid document
+// within 72 hours of its validation. Pass WithPrecheck() to verify this
+// before calling the API.
+func (a *Api) RejectDocument(ctx context.Context, docUuid string, reason string, opts ...StateChangeOption) (*RejectDocumentResponse, error) {
+	if err := a.checkStateChange(ctx, docUuid, reason, "reject", opts); err != nil {
+		return nil, err
+	}
+
+	endpointUrl := a.apiUrl(strings.ReplaceAll(REJECT_DOCUMENT_ENDPOINT, "{UUID}", url.PathEscape(docUuid)))
 
 	reqBody := RejectDocumentRequest{
 		DesiredStatus: "rejected",
@@ -605,7 +816,7 @@ func (a *Api) RejectDocument(docUuid string, reason string) (*RejectDocumentResp
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPut, endpointUrl, bytes.NewBuffer(b))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, endpointUrl, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
@@ -613,33 +824,27 @@ func (a *Api) RejectDocument(docUuid string, reason string) (*RejectDocumentResp
 	httpReq.Header.Add("Content-Type", "application/json")
 	httpReq.Header.Add("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(REJECT_DOCUMENT_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
 
 	var retval RejectDocumentResponse
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	if err := json.Unmarshal(respBytes, &retval); err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		// TODO: handle success
-		return &retval, nil
-	} else {
-		// TODO: handle error
-		return &retval, nil
-	}
+	return &retval, nil
 }
 
+// Page size cannot exceed this value for GetRecentDocuments.
+const GET_RECENT_DOCUMENTS_MAX_PAGE_SIZE = 100
+
 type GetRecentDocumentsQuery struct {
 	PageNo             int64   // Optional: number of the page to retrieve. Typically this parameter value is derived from initial parameter less call when caller learns total amount of page of certain size 	3 	Optional
 	PageSize           int64   // Optional: number of the documents to retrieve per page. Page size cannot exceed system configured maximum page size for this API 	20 	Optional
@@ -659,62 +864,62 @@ type GetRecentDocumentsQuery struct {
 }
 
 type GetRecentDocumentsResponse struct {
-	UUID                  string             `json:"uuid"`                  // 	Unique document ID in e-Invoice 	42S512YACQBRSRHYKBXBTGQG22
-	SubmissionUid         string             `json:"submissionUID"`         // 	Unique ID of the submission that document was part of 	XYE60M8ENDWA7V9TKBXBTGQG10
-	LongId                string             `json:"longId"`                // 	Unique long temporary Id that can be used to query document data anonymously 	YQH73576FY9VR57B…
-	InternalId            string             `json:"internalId"`            // 	Internal ID used in submission for the document 	PZ-234-A
-	TypeName              string             `json:"typeName"`              // 	Unique name of the document type that can be used in submission of the documents. 	invoice
-	TypeVersionName       string             `json:"typeVersionName"`       // 	Name of the document type version within the document type that can be used in document submission to identify document type version being submitted 	1.0
-	IssuerTIN             string             `json:"issuerTin"`             // 	TIN of issuer 	C2584563200
-	IssuerName            string             `json:"issuerName"`            // 	Issuer company name 	AMS Setia Jaya Sdn. Bhd.
-	ReceiverId            string             `json:"receiverId"`            // 	Optional: receiver registration number (can be national ID or foreigner ID). 	BRN example: 201901234567 - NRIC example: 770625015324 - Passport number example: A12345678 - Army number example: 551587706543
-	ReceiverName          string             `json:"receiverName"`          // 	Optional: receiver name (can be company name or person’s name) 	AMS Setia Jaya Sdn. Bhd.
-	DateTimeIssued        string             `json:"dateTimeIssued"`        // DateTime 	The date and time when the document was issued. 	2015-02-13T13:15:00Z
-	DateTimeReceived      string             `json:"dateTimeReceived"`      // DateTime 	The date and time when the document was submitted. 	2015-02-13T14:20:00Z
-	DateTimeValidated     string             `json:"dateTimeValidated"`     // DateTime 	The date and time when the document passed all validations and moved to the valid state. 	2015-02-13T14:20:00Z
-	TotalSales            string             `json:"totalSales"`            // Decimal 	Total sales amount of the document in MYR. 	10.10
-	TotalDiscount         string             `json:"totalDiscount"`         // Decimal 	Total discount amount of the document in MYR. 	50.00
-	NetAmount             string             `json:"netAmount"`             // Decimal 	Total net amount of the document in MYR. 	100.70
-	Total                 string             `json:"total"`                 // Decimal 	Total amount of the document in MYR. 	124.09
-	Status                string             `json:"status"`                // 	Status of the document - Submitted, Valid, Invalid, Cancelled 	Valid
-	CancelDateTime        string             `json:"cancelDateTime"`        // Date 	Refer to the document cancellation that has been initiated by the taxpayer “issuer” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
-	RejectRequestDateTime string             `json:"rejectRequestDateTime"` // 	Date 	Refer to the document rejection request that has been initiated by the taxpayer “receiver” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
-	DocumentStatusReason  string             `json:"documentStatusReason"`  // 	Mandatory: Reason of the cancellation or rejection of the document. 	Examples of reasons: Wrong buyer details or Wrong invoice details or any other reasons as appropriate
-	CreatedByUserId       string             `json:"createdByUserId"`       // 	User created the document. Can be ERP ID or User Email C1XXXXXXXX00:9e21b10c-41c4-9323-c590-95abcb6e4e4d, general.ams@supplier.com
-	SupplierTIN           string             `json:"supplierTIN"`           // 	TIN of issuer 	C2584563200
-	SupplierName          string             `json:"supplierName"`          // 	Supplier company name 	AMS Setia Jaya Sdn. Bhd.
-	SubmissionChannel     string             `json:"submissionChannel"`     // 	Channel through which document was introduced into the system 	possible values: ERP, Invoicing Portal, InvoicingMobileApp
-	IntermediaryName      string             `json:"intermediaryName"`      // 	Intermediary company name 	AMS Setia Jaya Sdn. Bhd.
-	IntermediaryTIN       string             `json:"intermediaryTIN"`       // 	TIN of intermediary 	C2584563200
-	BuyerName             string             `json:"buyerName"`             // 	Buyer company name 	AMS Setia Jaya Sdn. Bhd.
-	BuyerTIN              string             `json:"buyerTIN"`              // 	Tin of buyer 	C2584563200
-	Metadata              RecentDocumentMeta `json:"metadata"`              // 	Information about the results retrieved or results matching the query 	See structure
-	common.StandardErrResponse
+	Result   []RecentDocument `json:"result"`   // Document Summary[] 	List of the documents in the current page 	See structure
+	Metadata PageMetadata     `json:"metadata"` // Metadata 	Information about the results retrieved or results matching the query 	See structure
 }
 
-type RecentDocumentMeta struct {
-	TotalPages string `json:"totalPages"` // Number Total count of pages based on the supplied (or default) page size
-	TotalCount string `json:"totalCount"` // Number Total count of matching objects
+type RecentDocument struct {
+	UUID                  string      `json:"uuid"`                  // 	Unique document ID in e-Invoice 	42S512YACQBRSRHYKBXBTGQG22
+	SubmissionUid         string      `json:"submissionUID"`         // 	Unique ID of the submission that document was part of 	XYE60M8ENDWA7V9TKBXBTGQG10
+	LongId                string      `json:"longId"`                // 	Unique long temporary Id that can be used to query document data anonymously 	YQH73576FY9VR57B…
+	InternalId            string      `json:"internalId"`            // 	Internal ID used in submission for the document 	PZ-234-A
+	TypeName              string      `json:"typeName"`              // 	Unique name of the document type that can be used in submission of the documents. 	invoice
+	TypeVersionName       string      `json:"typeVersionName"`       // 	Name of the document type version within the document type that can be used in document submission to identify document type version being submitted 	1.0
+	IssuerTIN             string      `json:"issuerTin"`             // 	TIN of issuer 	C2584563200
+	IssuerName            string      `json:"issuerName"`            // 	Issuer company name 	AMS Setia Jaya Sdn. Bhd.
+	ReceiverId            string      `json:"receiverId"`            // 	Optional: receiver registration number (can be national ID or foreigner ID). 	BRN example: 201901234567 - NRIC example: 770625015324 - Passport number example: A12345678 - Army number example: 551587706543
+	ReceiverName          string      `json:"receiverName"`          // 	Optional: receiver name (can be company name or person’s name) 	AMS Setia Jaya Sdn. Bhd.
+	DateTimeIssued        string      `json:"dateTimeIssued"`        // DateTime 	The date and time when the document was issued. 	2015-02-13T13:15:00Z
+	DateTimeReceived      string      `json:"dateTimeReceived"`      // DateTime 	The date and time when the document was submitted. 	2015-02-13T14:20:00Z
+	DateTimeValidated     string      `json:"dateTimeValidated"`     // DateTime 	The date and time when the document passed all validations and moved to the valid state. 	2015-02-13T14:20:00Z
+	TotalSales            json.Number `json:"totalSales"`            // Decimal 	Total sales amount of the document in MYR. 	10.10
+	TotalDiscount         json.Number `json:"totalDiscount"`         // Decimal 	Total discount amount of the document in MYR. 	50.00
+	NetAmount             json.Number `json:"netAmount"`             // Decimal 	Total net amount of the document in MYR. 	100.70
+	Total                 json.Number `json:"total"`                 // Decimal 	Total amount of the document in MYR. 	124.09
+	Status                string      `json:"status"`                // 	Status of the document - Submitted, Valid, Invalid, Cancelled 	Valid
+	CancelDateTime        string      `json:"cancelDateTime"`        // Date 	Refer to the document cancellation that has been initiated by the taxpayer “issuer” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	RejectRequestDateTime string      `json:"rejectRequestDateTime"` // 	Date 	Refer to the document rejection request that has been initiated by the taxpayer “receiver” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	DocumentStatusReason  string      `json:"documentStatusReason"`  // 	Mandatory: Reason of the cancellation or rejection of the document. 	Examples of reasons: Wrong buyer details or Wrong invoice details or any other reasons as appropriate
+	CreatedByUserId       string      `json:"createdByUserId"`       // 	User created the document. Can be ERP ID or User Email C1XXXXXXXX00:9e21b10c-41c4-9323-c590-95abcb6e4e4d, general.ams@supplier.com
+	SupplierTIN           string      `json:"supplierTIN"`           // 	TIN of issuer 	C2584563200
+	SupplierName          string      `json:"supplierName"`          // 	Supplier company name 	AMS Setia Jaya Sdn. Bhd.
+	SubmissionChannel     string      `json:"submissionChannel"`     // 	Channel through which document was introduced into the system 	possible values: ERP, Invoicing Portal, InvoicingMobileApp
+	IntermediaryName      string      `json:"intermediaryName"`      // 	Intermediary company name 	AMS Setia Jaya Sdn. Bhd.
+	IntermediaryTIN       string      `json:"intermediaryTIN"`       // 	TIN of intermediary 	C2584563200
+	BuyerName             string      `json:"buyerName"`             // 	Buyer company name 	AMS Setia Jaya Sdn. Bhd.
+	BuyerTIN              string      `json:"buyerTIN"`              // 	Tin of buyer 	C2584563200
 }
 
-func (a *Api) GetRecentDocuments(query *GetRecentDocumentsQuery) (*GetRecentDocumentsResponse, error) {
-	httpReq, err := buildGetRecentDocumentsRequest(query)
+// This API allows caller to get the documents sent or received by the
+// taxpayer in the last 31 days, one page at a time.
+//
+// Use NewRecentDocumentsIterator to go through every page.
+func (a *Api) GetRecentDocuments(ctx context.Context, query *GetRecentDocumentsQuery) (*GetRecentDocumentsResponse, error) {
+	httpReq, err := buildGetRecentDocumentsRequest(ctx, a.apiBaseUrl, query)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(GET_RECENT_DOCUMENTS_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
 
 	var retval GetRecentDocumentsResponse
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
 
 	if err := json.Unmarshal(respBytes, &retval); err != nil {
 		return nil, err
@@ -723,10 +928,10 @@ func (a *Api) GetRecentDocuments(query *GetRecentDocumentsQuery) (*GetRecentDocu
 	return &retval, nil
 }
 
-func buildGetRecentDocumentsRequest(query *GetRecentDocumentsQuery) (*http.Request, error) {
-	endpointUrl := common.SANDBOX_API_BASE_URL + GET_RECENT_DOCUMENTS_ENDPOINT
+func buildGetRecentDocumentsRequest(ctx context.Context, baseUrl string, query *GetRecentDocumentsQuery) (*http.Request, error) {
+	endpointUrl := baseUrl + GET_RECENT_DOCUMENTS_ENDPOINT
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -800,6 +1005,9 @@ func buildGetRecentDocumentsRequest(query *GetRecentDocumentsQuery) (*http.Reque
 
 const GET_SUBMISSION_ENDPOINT = "/api/v1.0/documentsubmissions/{submissionUid}"
 
+// Page size cannot exceed this value for GetSubmission.
+const GET_SUBMISSION_MAX_PAGE_SIZE = 100
+
 type GetSubmissionQuery struct {
 	PageNo   int64
 	PageSize int64
@@ -807,7 +1015,7 @@ type GetSubmissionQuery struct {
 
 type GetSubmissionResponse struct {
 	SubmissionUid    string                      `json:"submissionUid"`    // String 	Unique document submission ID in e-Invoice 	HJSD135P2S7D8IU
-	DocumentCount    string                      `json:"documentCount"`    // Number 	Total count of documents in submission that were accepted for processing 	234
+	DocumentCount    int64                       `json:"documentCount"`    // Number 	Total count of documents in submission that were accepted for processing 	234
 	DateTimeReceived string                      `json:"dateTimeReceived"` // DateTime 	The date and time when the submission was received by e-Invoice. 	2015-02-13T14:20:10Z
 	OverallStatus    string                      `json:"overallStatus"`    // String 	Overall status of the batch processing. Values: in progress, valid, partially valid, invalid 	valid
 	DocumentSummary  []SubmissionDocumentSummary `json:"documentSummary"`  // Document Summary[] 	List of the retrieved batch documents in current page. 	See structure.
@@ -815,28 +1023,28 @@ type GetSubmissionResponse struct {
 }
 
 type SubmissionDocumentSummary struct {
-	UUID                  string `json:"uuid"`                  // String 	Unique document ID in e-Invoice 	F9D425P6DS7D8IU
-	SubmissionUid         string `json:"submissionUid"`         // String 	Unique ID of the submission the document was part of 	HJSD135P2S7D8IU
-	LongId                string `json:"longId"`                // String 	Unique long temporary Id that can be used to query document data anonymously. The long id will be returned only for valid documents 	LIJAF97HJJKH 8298KHADH0990 8570FDKK9S2LSIU HB377373
-	InternalId            string `json:"internalId"`            // String 	Internal ID used in submission for the document 	PZ-234-A
-	TypeName              string `json:"typeName"`              // String 	Unique name of the document type that can be used in submission of the documents. 	invoice
-	TypeVersionName       string `json:"typeVersionName"`       // String 	Name of the document type version within the document type that can be used in document submission to identify document type version being submitted 	1.0
-	IssuerTIN             string `json:"issuerTin"`             // String 	TIN of issuer 	C2584563200
-	IssuerName            string `json:"issuerName"`            // String 	Issuer company name 	AMS Setia Jaya Sdn. Bhd.
-	ReceiverId            string `json:"receiverId"`            // String 	Optional: receiver registration number (can be national ID or foreigner ID). 	201901234567
-	ReceiverName          string `json:"receiverName"`          // String 	Optional: receiver name (can be company name or person’s name) 	AMS Setia Jaya Sdn. Bhd.
-	DateTimeIssued        string `json:"dateTimeIssued"`        // DateTime 	The date and time when the document was issued in the UTC format. 	2015-02-13T13:15:10Z
-	DateTimeReceived      string `json:"dateTimeReceived"`      // DateTime 	The date and time when the document was submitted in the UTC format. 	2015-02-13T13:15:10Z
-	DateTimeValidated     string `json:"dateTimeValidated"`     // DateTime 	The date and time when the document passed all validations and moved to the valid state. 	2015-02-13T13:15:10Z
-	TotalExcludingTax     string `json:"totalExcludingTax"`     // Decimal 	Total sales amount of the document in MYR. 	10.10
-	TotalDiscount         string `json:"totalDiscount"`         // Decimal 	Total discount amount of the document in MYR. 	50.00
-	TotalNetAmount        string `json:"totalNetAmount"`        // Decimal 	Total net amount of the document in MYR. 	100.70
-	TotalPayableAmount    string `json:"totalPayableAmount"`    // Decimal 	Total amount of the document in MYR. 	124.09
-	Status                string `json:"status"`                // String 	Status of the document - Submitted, Valid, Invalid, Cancelled 	Valid
-	CancelDateTime        string `json:"cancelDateTime"`        // Date 	Refer to the document cancellation that has been initiated by the taxpayer ‘issuer’ of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
-	RejectRequestDateTime string `json:"rejectRequestDateTime"` // Date 	Refer to the document rejection request that has been initiated by the taxpayer ‘receiver’ of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
-	DocumentStatusReason  string `json:"documentStatusReason"`  // String 	Mandatory: Reason of the cancellation or rejection of the document. 	Examples of reasons: Wrong buyer details or Wrong invoice details or any other reasons as appropriate
-	CreatedByUserId       string `json:"createdByUserId"`       // String 	User created the document. Can be ERP ID or User Email 	1XXXXXXXX00:9e21b10c-41c4-9323-c590-95abcb6e4e4d general.ams@supplier.com
+	UUID                  string      `json:"uuid"`                  // String 	Unique document ID in e-Invoice 	F9D425P6DS7D8IU
+	SubmissionUid         string      `json:"submissionUid"`         // String 	Unique ID of the submission the document was part of 	HJSD135P2S7D8IU
+	LongId                string      `json:"longId"`                // String 	Unique long temporary Id that can be used to query document data anonymously. The long id will be returned only for valid documents 	LIJAF97HJJKH 8298KHADH0990 8570FDKK9S2LSIU HB377373
+	InternalId            string      `json:"internalId"`            // String 	Internal ID used in submission for the document 	PZ-234-A
+	TypeName              string      `json:"typeName"`              // String 	Unique name of the document type that can be used in submission of the documents. 	invoice
+	TypeVersionName       string      `json:"typeVersionName"`       // String 	Name of the document type version within the document type that can be used in document submission to identify document type version being submitted 	1.0
+	IssuerTIN             string      `json:"issuerTin"`             // String 	TIN of issuer 	C2584563200
+	IssuerName            string      `json:"issuerName"`            // String 	Issuer company name 	AMS Setia Jaya Sdn. Bhd.
+	ReceiverId            string      `json:"receiverId"`            // String 	Optional: receiver registration number (can be national ID or foreigner ID). 	201901234567
+	ReceiverName          string      `json:"receiverName"`          // String 	Optional: receiver name (can be company name or person’s name) 	AMS Setia Jaya Sdn. Bhd.
+	DateTimeIssued        string      `json:"dateTimeIssued"`        // DateTime 	The date and time when the document was issued in the UTC format. 	2015-02-13T13:15:10Z
+	DateTimeReceived      string      `json:"dateTimeReceived"`      // DateTime 	The date and time when the document was submitted in the UTC format. 	2015-02-13T13:15:10Z
+	DateTimeValidated     string      `json:"dateTimeValidated"`     // DateTime 	The date and time when the document passed all validations and moved to the valid state. 	2015-02-13T13:15:10Z
+	TotalExcludingTax     json.Number `json:"totalExcludingTax"`     // Decimal 	Total sales amount of the document in MYR. 	10.10
+	TotalDiscount         json.Number `json:"totalDiscount"`         // Decimal 	Total discount amount of the document in MYR. 	50.00
+	TotalNetAmount        json.Number `json:"totalNetAmount"`        // Decimal 	Total net amount of the document in MYR. 	100.70
+	TotalPayableAmount    json.Number `json:"totalPayableAmount"`    // Decimal 	Total amount of the document in MYR. 	124.09
+	Status                string      `json:"status"`                // String 	Status of the document - Submitted, Valid, Invalid, Cancelled 	Valid
+	CancelDateTime        string      `json:"cancelDateTime"`        // Date 	Refer to the document cancellation that has been initiated by the taxpayer ‘issuer’ of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	RejectRequestDateTime string      `json:"rejectRequestDateTime"` // Date 	Refer to the document rejection request that has been initiated by the taxpayer ‘receiver’ of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	DocumentStatusReason  string      `json:"documentStatusReason"`  // String 	Mandatory: Reason of the cancellation or rejection of the document. 	Examples of reasons: Wrong buyer details or Wrong invoice details or any other reasons as appropriate
+	CreatedByUserId       string      `json:"createdByUserId"`       // String 	User created the document. Can be ERP ID or User Email 	1XXXXXXXX00:9e21b10c-41c4-9323-c590-95abcb6e4e4d general.ams@supplier.com
 }
 
 // This API allows caller to get details of a single submission to check
@@ -844,10 +1052,10 @@ type SubmissionDocumentSummary struct {
 // and getting back unique submission identifier.
 //
 // This API is available to submitter only as it might contain documents issued to multiple receivers.
-func (a *Api) GetSubmission(submissionUid string, query *GetSubmissionQuery) (*GetSubmissionResponse, error) {
-	endpointUrl := strings.ReplaceAll(GET_SUBMISSION_ENDPOINT, "{submissionUid}", submissionUid)
+func (a *Api) GetSubmission(ctx context.Context, submissionUid string, query *GetSubmissionQuery) (*GetSubmissionResponse, error) {
+	endpointUrl := a.apiUrl(strings.ReplaceAll(GET_SUBMISSION_ENDPOINT, "{submissionUid}", url.PathEscape(submissionUid)))
 
-	httpReq, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -862,22 +1070,71 @@ func (a *Api) GetSubmission(submissionUid string, query *GetSubmissionQuery) (*G
 		if query.PageSize > 0 {
 			q.Add("pageSize", fmt.Sprintf("%d", query.PageSize))
 		}
+
+		httpReq.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, respBytes, err := a.do(GET_SUBMISSION_ENDPOINT, httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
 
 	var retval GetSubmissionResponse
 
-	respBytes, err := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(respBytes, &retval); err != nil {
+		return nil, err
+	}
+
+	return &retval, nil
+}
+
+const GET_DOCUMENT_ENDPOINT = "/api/v1.0/documents/{uuid}/raw"
+
+type GetDocumentResponse struct {
+	SubmissionDocumentSummary
+	Document string `json:"document"` // String 	Document in the format it was submitted in, XML or JSON
+}
+
+// Parse the raw document into a UBL invoice, in whichever format, XML or
+// JSON, it was submitted in.
+func (r *GetDocumentResponse) Invoice() (*ubl.UBL_Invoice, error) {
+	raw := strings.TrimSpace(r.Document)
+
+	switch {
+	case strings.HasPrefix(raw, "<"):
+		return ubl.ParseInvoice([]byte(raw))
+	case strings.HasPrefix(raw, "{"):
+		return ubl.ParseInvoiceJSON([]byte(raw))
+	}
+
+	return nil, fmt.Errorf("document %s is neither XML nor JSON", r.UUID)
+}
+
+// This API allows caller to get the full document as it was submitted,
+// along with its metadata.
+func (a *Api) GetDocument(ctx context.Context, docUuid string) (*GetDocumentResponse, error) {
+	endpointUrl := a.apiUrl(strings.ReplaceAll(GET_DOCUMENT_ENDPOINT, "{uuid}", url.PathEscape(docUuid)))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	resp, respBytes, err := a.do(GET_DOCUMENT_ENDPOINT, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
+
+	var retval GetDocumentResponse
+
 	if err := json.Unmarshal(respBytes, &retval); err != nil {
 		return nil, err
 	}
@@ -885,23 +1142,275 @@ func (a *Api) GetSubmission(submissionUid string, query *GetSubmissionQuery) (*G
 	return &retval, nil
 }
 
-const GET_DOCUMENT_ENDPOINT = "/api/v1.0/documents/{uuid}/raw"
+const GET_DOCUMENT_DETAILS_ENDPOINT = "/api/v1.0/documents/{uuid}/details"
 
-func (a *Api) GetDocument(docUuid string) (any, error) {
-	// TODO
-	return nil, nil
+type GetDocumentDetailsResponse struct {
+	SubmissionDocumentSummary
+	ValidationResults DocumentValidationResults `json:"validationResults"` // Validation Results 	Validation results of the document, only present for Invalid documents 	See structure
 }
 
-const GET_DOCUMENT_DETAILS_ENDPOINT = "/api/v1.0/documents/{uuid}/details"
+type DocumentValidationResults struct {
+	Status          string                   `json:"status"`          // String 	Overall status of the validation. Values: Submitted, Valid, Invalid 	Invalid
+	ValidationSteps []DocumentValidationStep `json:"validationSteps"` // Validation Step[] 	List of the validation steps the document went through 	See structure
+}
+
+type DocumentValidationStep struct {
+	Name   string              `json:"name"`   // String 	Name of the validation step 	Step03-Duplicated Submission Validator
+	Status string              `json:"status"` // String 	Status of the validation step. Values: Submitted, Valid, Invalid 	Invalid
+	Error  *common.ErrResponse `json:"error"`  // Error 	Only present for invalid steps 	See standard error response
+}
+
+// The validation steps the document failed.
+func (r *DocumentValidationResults) FailedSteps() []DocumentValidationStep {
+	var failed []DocumentValidationStep
+
+	for _, step := range r.ValidationSteps {
+		if step.Status == "Invalid" {
+			failed = append(failed, step)
+		}
+	}
 
-func (a *Api) GetDocumentDetails(docUuid string) (any, error) {
-	// TODO
-	return nil, nil
+	return failed
+}
+
+// This API allows caller to get the metadata of a document along with
+// the results of its validation.
+func (a *Api) GetDocumentDetails(ctx context.Context, docUuid string) (*GetDocumentDetailsResponse, error) {
+	endpointUrl := a.apiUrl(strings.ReplaceAll(GET_DOCUMENT_DETAILS_ENDPOINT, "{uuid}", url.PathEscape(docUuid)))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, respBytes, err := a.do(GET_DOCUMENT_DETAILS_ENDPOINT, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
+
+	var retval GetDocumentDetailsResponse
+
+	if err := json.Unmarshal(respBytes, &retval); err != nil {
+		return nil, err
+	}
+
+	return &retval, nil
 }
 
 const SEARCH_DOCUMENTS_ENDPOINT = "/api/v1.0/documents/search"
 
-func (a *Api) SearchDocuments() (any, error) {
-	// TODO
-	return nil, nil
+// Page size cannot exceed this value for SearchDocuments.
+const SEARCH_DOCUMENTS_MAX_PAGE_SIZE = 100
+
+// Either the submission date or the issue date range is mandatory,
+// and the range cannot exceed 30 days.
+type SearchDocumentsQuery struct {
+	Uuid               *string // Optional: unique ID of the e-Invoice to be searched 	F9D425P6DS7D8IU 	Optional
+	SubmissionDateFrom *string // Optional: The start date and time when the document was submitted to the e-Invoice API, Time to be supplied in UTC timezone. Mandatory when ‘issueDateFrom’ is not provided 	2022-11-25T01:59:10Z 	Optional
+	SubmissionDateTo   *string // Optional: The end date and time when the document was submitted to the e-Invoice API, Time to be supplied in UTC timezone. Mandatory when ‘issueDateTo’ is not provided 	2022-12-22T23:59:59Z 	Optional
+	PageSize           int64   // Optional: number of the documents to retrieve per page. Page size cannot exceed system configured maximum page size for this API which is 100 	20 	Optional
+	PageNo             int64   // Optional: number of the page to retrieve 	3 	Optional
+	IssueDateFrom      *string // Optional: The start date and time when the document was issued. Mandatory when ‘submissionDateFrom’ is not provided 	2022-11-25T01:59:10Z 	Optional
+	IssueDateTo        *string // Optional: The end date and time when the document was issued. Mandatory when ‘submissionDateTo’ is not provided 	2022-12-22T23:59:59Z 	Optional
+	InvoiceDirection   *string // Optional: direction of the document. Possible values: (Sent, Received) 	Sent 	Optional
+	Status             *string // Optional: status of the document. Possible values: (Valid, Invalid, Cancelled, Submitted) 	Valid 	Optional
+	DocumentType       *string // Optional: Document type code. 	01 	Optional
+	SearchQuery        *string // Optional: Search documents by uuid, buyerTIN, supplierTIN, buyerName, supplierName, internalID, total 	1234567890 	Optional
+	ReceiverId         *string // Optional: Document recipient identifier. Only can be used when ‘InvoiceDirection’ filter is set to Sent. Possible values: (Business registration number, National ID(IC), Passport Number, Army ID) 	201901234567 	Optional
+	ReceiverIdType     *string // Optional: Document recipient identifier type. Only can be used when ‘InvoiceDirection’ filter is set to Sent. Possible values: (BRN, PASSPORT, NRIC, ARMY) This is mandatory in case the receiverId is provided 	PASSPORT 	Optional
+	ReceiverTin        *string // Optional: Document recipient TIN. Only can be used when ‘InvoiceDirection’ filter is set to Sent. 	C2584563200 	Optional
+	IssuerTin          *string // Optional: Document issuer TIN. Only can be used when ‘InvoiceDirection’ filter is set to Received. 	C2584563200 	Optional
+	IssuerId           *string // Optional: Document issuer identifier. Only can be used when ‘InvoiceDirection’ filter is set to Received. Possible values: (Business registration number, National ID(IC), Passport Number, Army ID) 	201901234567 	Optional
+	IssuerIdType       *string // Optional: Document issuer identifier type. Only can be used when ‘InvoiceDirection’ filter is set to Received. Possible values: (BRN, PASSPORT, NRIC, ARMY) This is mandatory in case the issuerId is provided 	PASSPORT 	Optional
+}
+
+type SearchDocumentsResponse struct {
+	Result   []SearchDocumentsResult `json:"result"`   // Document Summary[] 	List of documents matching the query in the current page 	See structure
+	Metadata PageMetadata            `json:"metadata"` // Metadata 	Information about the results retrieved or results matching the query 	See structure
+}
+
+type SearchDocumentsResult struct {
+	UUID                  string      `json:"uuid"`                  // String 	Unique document ID in e-Invoice 	42S512YACQBRSRHYKBXBTGQG22
+	SubmissionUid         string      `json:"submissionUID"`         // String 	Unique ID of the submission that document was part of 	XYE60M8ENDWA7V9TKBXBTGQG10
+	LongId                string      `json:"longId"`                // String 	Unique long temporary Id that can be used to query document data anonymously 	YQH73576FY9VR57B…
+	InternalId            string      `json:"internalId"`            // String 	Internal ID used in submission for the document 	PZ-234-A
+	TypeName              string      `json:"typeName"`              // String 	Unique name of the document type that can be used in submission of the documents. 	invoice
+	TypeVersionName       string      `json:"typeVersionName"`       // String 	Name of the document type version within the document type that can be used in document submission to identify document type version being submitted 	1.0
+	IssuerTIN             string      `json:"issuerTin"`             // String 	TIN of issuer 	C2584563200
+	IssuerName            string      `json:"issuerName"`            // String 	Issuer company name 	AMS Setia Jaya Sdn. Bhd.
+	ReceiverId            string      `json:"receiverId"`            // String 	Optional: receiver registration number (can be national ID or foreigner ID). 	201901234567
+	ReceiverName          string      `json:"receiverName"`          // String 	Optional: receiver name (can be company name or person’s name) 	AMS Setia Jaya Sdn. Bhd.
+	DateTimeIssued        string      `json:"dateTimeIssued"`        // DateTime 	The date and time when the document was issued. 	2015-02-13T13:15:00Z
+	DateTimeReceived      string      `json:"dateTimeReceived"`      // DateTime 	The date and time when the document was submitted. 	2015-02-13T14:20:00Z
+	DateTimeValidated     string      `json:"dateTimeValidated"`     // DateTime 	The date and time when the document passed all validations and moved to the valid state. 	2015-02-13T14:20:00Z
+	TotalExcludingTax     json.Number `json:"totalExcludingTax"`     // Decimal 	Total sales amount of the document in MYR. 	10.10
+	TotalDiscount         json.Number `json:"totalDiscount"`         // Decimal 	Total discount amount of the document in MYR. 	50.00
+	TotalNetAmount        json.Number `json:"totalNetAmount"`        // Decimal 	Total net amount of the document in MYR. 	100.70
+	TotalPayableAmount    json.Number `json:"totalPayableAmount"`    // Decimal 	Total amount of the document in MYR. 	124.09
+	Status                string      `json:"status"`                // String 	Status of the document - Submitted, Valid, Invalid, Cancelled 	Valid
+	CancelDateTime        string      `json:"cancelDateTime"`        // DateTime 	Refer to the document cancellation that has been initiated by the taxpayer “issuer” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	RejectRequestDateTime string      `json:"rejectRequestDateTime"` // DateTime 	Refer to the document rejection request that has been initiated by the taxpayer “receiver” of the document on the system, will be in UTC format 	2021-02-25T01:59:10Z
+	DocumentStatusReason  string      `json:"documentStatusReason"`  // String 	Reason of the cancellation or rejection of the document. 	Wrong buyer details
+	CreatedByUserId       string      `json:"createdByUserId"`       // String 	User created the document. Can be ERP ID or User Email 	general.ams@supplier.com
+	SupplierTIN           string      `json:"supplierTIN"`           // String 	TIN of issuer 	C2584563200
+	SupplierName          string      `json:"supplierName"`          // String 	Supplier company name 	AMS Setia Jaya Sdn. Bhd.
+	SubmissionChannel     string      `json:"submissionChannel"`     // String 	Channel through which document was introduced into the system 	possible values: ERP, Invoicing Portal, InvoicingMobileApp
+	IntermediaryName      string      `json:"intermediaryName"`      // String 	Intermediary company name 	AMS Setia Jaya Sdn. Bhd.
+	IntermediaryTIN       string      `json:"intermediaryTIN"`       // String 	TIN of intermediary 	C2584563200
+	BuyerName             string      `json:"buyerName"`             // String 	Buyer company name 	AMS Setia Jaya Sdn. Bhd.
+	BuyerTIN              string      `json:"buyerTIN"`              // String 	TIN of buyer 	C2584563200
+}
+
+// Pagination metadata returned by the endpoints that list documents.
+type PageMetadata struct {
+	TotalPages int64 `json:"totalPages"` // Number 	Total count of pages based on the supplied (or default) page size
+	TotalCount int64 `json:"totalCount"` // Number 	Total count of matching objects
+}
+
+// The counts are sent either as numbers or as strings.
+func (m *PageMetadata) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		TotalPages json.Number `json:"totalPages"`
+		TotalCount json.Number `json:"totalCount"`
+	}
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		value json.Number
+		dst   *int64
+	}{
+		{raw.TotalPages, &m.TotalPages},
+		{raw.TotalCount, &m.TotalCount},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		n, err := field.value.Int64()
+		if err != nil {
+			return err
+		}
+
+		*field.dst = n
+	}
+
+	return nil
+}
+
+// This API allows caller to search documents sent or received by the
+// taxpayer, within a date range of at most 30 days.
+//
+// Use NewSearchDocumentsIterator to go through every page of results.
+func (a *Api) SearchDocuments(ctx context.Context, query *SearchDocumentsQuery) (*SearchDocumentsResponse, error) {
+	httpReq, err := buildSearchDocumentsRequest(ctx, a.apiBaseUrl, query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, respBytes, err := a.do(SEARCH_DOCUMENTS_ENDPOINT, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, respBytes)
+	}
+
+	var retval SearchDocumentsResponse
+
+	if err := json.Unmarshal(respBytes, &retval); err != nil {
+		return nil, err
+	}
+
+	return &retval, nil
+}
+
+func buildSearchDocumentsRequest(ctx context.Context, baseUrl string, query *SearchDocumentsQuery) (*http.Request, error) {
+	endpointUrl := baseUrl + SEARCH_DOCUMENTS_ENDPOINT
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	queryParam := httpReq.URL.Query()
+
+	if query.Uuid != nil {
+		queryParam.Add("uuid", *query.Uuid)
+	}
+
+	if query.SubmissionDateFrom != nil {
+		queryParam.Add("submissionDateFrom", *query.SubmissionDateFrom)
+	}
+
+	if query.SubmissionDateTo != nil {
+		queryParam.Add("submissionDateTo", *query.SubmissionDateTo)
+	}
+
+	if query.PageSize > 0 {
+		queryParam.Add("pageSize", fmt.Sprintf("%d", query.PageSize))
+	}
+
+	if query.PageNo > 0 {
+		queryParam.Add("pageNo", fmt.Sprintf("%d", query.PageNo))
+	}
+
+	if query.IssueDateFrom != nil {
+		queryParam.Add("issueDateFrom", *query.IssueDateFrom)
+	}
+
+	if query.IssueDateTo != nil {
+		queryParam.Add("issueDateTo", *query.IssueDateTo)
+	}
+
+	if query.InvoiceDirection != nil {
+		queryParam.Add("invoiceDirection", *query.InvoiceDirection)
+	}
+
+	if query.Status != nil {
+		queryParam.Add("status", *query.Status)
+	}
+
+	if query.DocumentType != nil {
+		queryParam.Add("documentType", *query.DocumentType)
+	}
+
+	if query.SearchQuery != nil {
+		queryParam.Add("searchQuery", *query.SearchQuery)
+	}
+
+	if query.ReceiverId != nil {
+		queryParam.Add("receiverId", *query.ReceiverId)
+	}
+
+	if query.ReceiverIdType != nil {
+		queryParam.Add("receiverIdType", *query.ReceiverIdType)
+	}
+
+	if query.ReceiverTin != nil {
+		queryParam.Add("receiverTin", *query.ReceiverTin)
+	}
+
+	if query.IssuerTin != nil {
+		queryParam.Add("issuerTin", *query.IssuerTin)
+	}
+
+	if query.IssuerId != nil {
+		queryParam.Add("issuerId", *query.IssuerId)
+	}
+
+	if query.IssuerIdType != nil {
+		queryParam.Add("issuerIdType", *query.IssuerIdType)
+	}
+
+	httpReq.URL.RawQuery = queryParam.Encode()
+
+	return httpReq, nil
 }
@@ -1,10 +1,22 @@
 package platform
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/common"
+	"github.com/programmer-my/einvoice-go/ubl"
 )
 
 func TestUnmarshalTaxPayerSuccessLoginResp(t *testing.T) {
@@ -185,12 +197,12 @@ func TestUnmarshalGetDocumentTypesResponseSuccess(t *testing.T) {
 		t.Errorf("expected Description to be %s, got %s", expectedDescription, docType.Description)
 	}
 
-	if docType.ActiveFrom != expectedActiveFrom {
-		t.Errorf("expected ActiveFrom to be %s, got %s", expectedActiveFrom, docType.ActiveFrom)
+	if formatActiveTime(docType.ActiveFrom) != expectedActiveFrom {
+		t.Errorf("expected ActiveFrom to be %s, got %s", expectedActiveFrom, formatActiveTime(docType.ActiveFrom))
 	}
 
-	if docType.ActiveTo != expectedActiveTo {
-		t.Errorf("expected ActiveTo to be %s, got %s", expectedActiveTo, docType.ActiveTo)
+	if formatActiveTime(docType.ActiveTo) != expectedActiveTo {
+		t.Errorf("expected ActiveTo to be %s, got %s", expectedActiveTo, formatActiveTime(docType.ActiveTo))
 	}
 
 	if len(docType.Versions) != expectedDocTypeVersionLen {
@@ -217,12 +229,12 @@ func TestUnmarshalGetDocumentTypesResponseSuccess(t *testing.T) {
 		t.Errorf("expected version description to be %s, got %s", expectedVersionDescription, docTypeVersion1.Description)
 	}
 
-	if docTypeVersion1.ActiveFrom != expectedActiveFrom {
-		t.Errorf("expected version active from to be %s, got %s", expectedVersionActiveFrom, docTypeVersion1.ActiveFrom)
+	if formatActiveTime(docTypeVersion1.ActiveFrom) != expectedActiveFrom {
+		t.Errorf("expected version active from to be %s, got %s", expectedVersionActiveFrom, formatActiveTime(docTypeVersion1.ActiveFrom))
 	}
 
-	if docTypeVersion1.ActiveTo != expectedVersionActiveTo {
-		t.Errorf("expected version active to to be %s, got %s", expectedVersionActiveTo, docTypeVersion1.ActiveTo)
+	if formatActiveTime(docTypeVersion1.ActiveTo) != expectedVersionActiveTo {
+		t.Errorf("expected version active to to be %s, got %s", expectedVersionActiveTo, formatActiveTime(docTypeVersion1.ActiveTo))
 	}
 
 	if docTypeVersion1.VersionNumber != expectedVersionVersionNumber {
@@ -401,12 +413,12 @@ func TestUnmarshalGetDocumentTypeByIdResponseSuccess(t *testing.T) {
 		t.Errorf("expected description to be %s, got %s", expectedDescription, resp.Description)
 	}
 
-	if resp.ActiveFrom != expectedActiveFrom {
-		t.Errorf("expected activeFrom to be %s, got %s", expectedActiveFrom, resp.ActiveFrom)
+	if formatActiveTime(resp.ActiveFrom) != expectedActiveFrom {
+		t.Errorf("expected activeFrom to be %s, got %s", expectedActiveFrom, formatActiveTime(resp.ActiveFrom))
 	}
 
-	if resp.ActiveTo != expectedActiveTo {
-		t.Errorf("expected activeTo to be %s, got %s", expectedActiveTo, resp.ActiveTo)
+	if formatActiveTime(resp.ActiveTo) != expectedActiveTo {
+		t.Errorf("expected activeTo to be %s, got %s", expectedActiveTo, formatActiveTime(resp.ActiveTo))
 	}
 
 	versions := resp.Versions
@@ -444,12 +456,12 @@ func TestUnmarshalGetDocumentTypeByIdResponseSuccess(t *testing.T) {
 		t.Errorf("expected workflowParam.value to be %d, got %d", expectedWfpValue, wfp.Value)
 	}
 
-	if wfp.ActiveFrom != expectedWfpActiveFrom {
-		t.Errorf("expected workflowParam.activeFrom to be %s, got %s", expectedWfpActiveFrom, wfp.ActiveFrom)
+	if formatActiveTime(wfp.ActiveFrom) != expectedWfpActiveFrom {
+		t.Errorf("expected workflowParam.activeFrom to be %s, got %s", expectedWfpActiveFrom, formatActiveTime(wfp.ActiveFrom))
 	}
 
-	if wfp.ActiveTo != expectedWfpActiveTo {
-		t.Errorf("expected workflowParam.activeTo to be %s, got %s", expectedWfpActiveTo, wfp.ActiveTo)
+	if formatActiveTime(wfp.ActiveTo) != expectedWfpActiveTo {
+		t.Errorf("expected workflowParam.activeTo to be %s, got %s", expectedWfpActiveTo, formatActiveTime(wfp.ActiveTo))
 	}
 }
 
@@ -502,12 +514,12 @@ func TestUnmarshalGetDocumentTypeVersionResponse(t *testing.T) {
 		t.Errorf("expected status to be %s, got %s", expectedStatus, resp.Status)
 	}
 
-	if resp.ActiveFrom != expectedActiveFrom {
-		t.Errorf("expected activeFrom to be %s, got %s", expectedActiveFrom, resp.ActiveFrom)
+	if formatActiveTime(resp.ActiveFrom) != expectedActiveFrom {
+		t.Errorf("expected activeFrom to be %s, got %s", expectedActiveFrom, formatActiveTime(resp.ActiveFrom))
 	}
 
-	if resp.ActiveTo != expectedActiveTo {
-		t.Errorf("expected activeTo to be %s, got %s", expectedActiveTo, resp.ActiveTo)
+	if formatActiveTime(resp.ActiveTo) != expectedActiveTo {
+		t.Errorf("expected activeTo to be %s, got %s", expectedActiveTo, formatActiveTime(resp.ActiveTo))
 	}
 
 	if resp.JsonSchema != expectedJsonSchema {
@@ -521,7 +533,7 @@ func TestUnmarshalGetDocumentTypeVersionResponse(t *testing.T) {
 
 func TestBuildGetRecentDocumentsRequestEmpty(t *testing.T) {
 	query := GetRecentDocumentsQuery{}
-	req, err := buildGetRecentDocumentsRequest(&query)
+	req, err := buildGetRecentDocumentsRequest(context.Background(), common.SANDBOX_API_BASE_URL, &query)
 
 	if err != nil {
 		t.Errorf("unexpected error when building request: %s", err)
@@ -568,7 +580,7 @@ func TestBuildGetRecentDocumentsRequestFullDirectionSent(t *testing.T) {
 		IssuerTin:          &issuerTin,
 		IssuerId:           &issuerId,
 	}
-	req, err := buildGetRecentDocumentsRequest(&query)
+	req, err := buildGetRecentDocumentsRequest(context.Background(), common.SANDBOX_API_BASE_URL, &query)
 
 	if err != nil {
 		t.Errorf("unexpected error when building request: %s", err)
@@ -607,3 +619,696 @@ func TestBuildGetRecentDocumentsRequestFullDirectionSent(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDocument(t *testing.T) {
+	raw := []byte(`<?xml version="1.0" encoding="UTF-8"?><Invoice></Invoice>`)
+
+	doc := NewDocument(FORMAT_XML, "INV-0001", raw)
+
+	hash := sha256.Sum256(raw)
+	expectedHash := hex.EncodeToString(hash[:])
+	expectedDocument := base64.StdEncoding.EncodeToString(raw)
+
+	if doc.Format != FORMAT_XML {
+		t.Errorf("expected format to be %s, got %s", FORMAT_XML, doc.Format)
+	}
+
+	if doc.CodeNumber != "INV-0001" {
+		t.Errorf("expected codeNumber to be %s, got %s", "INV-0001", doc.CodeNumber)
+	}
+
+	if doc.Document != expectedDocument {
+		t.Errorf("expected document to be %s, got %s", expectedDocument, doc.Document)
+	}
+
+	if doc.DocumentSHA256 != expectedHash {
+		t.Errorf("expected documentHash to be %s, got %s", expectedHash, doc.DocumentSHA256)
+	}
+}
+
+func TestUnmarshalSubmitDocumentResponse(t *testing.T) {
+	jsonStr := `{
+		"submissionUid": "HJSD135P2S7D8IU",
+		"acceptedDocuments": [
+			{
+				"uuid": "F9D425P6DS7D8IU",
+				"invoiceCodeNumber": "INV12345"
+			}
+		],
+		"rejectedDocuments": [
+			{
+				"invoiceCodeNumber": "INV12346",
+				"error": {
+					"code": "ValidationError",
+					"errorCode": "DS302",
+					"error": "Invalid structured submission"
+				}
+			}
+		]
+	}`
+
+	var resp SubmitDocumentResponse
+
+	if err := json.Unmarshal([]byte(jsonStr), &resp); err != nil {
+		t.Fatalf("unexpected error when unmarshalling JSON: %s", err)
+	}
+
+	expectedSubmissionUid := "HJSD135P2S7D8IU"
+	if resp.SubmissionUID != expectedSubmissionUid {
+		t.Errorf("expected submissionUid to be %s, got %s", expectedSubmissionUid, resp.SubmissionUID)
+	}
+
+	if len(resp.AcceptedDocuments) != 1 {
+		t.Fatalf("expected len(acceptedDocuments) to be %d, got %d", 1, len(resp.AcceptedDocuments))
+	}
+
+	accepted := resp.AcceptedDocuments[0]
+	if accepted.UUID != "F9D425P6DS7D8IU" {
+		t.Errorf("expected accepted uuid to be %s, got %s", "F9D425P6DS7D8IU", accepted.UUID)
+	}
+
+	if accepted.InvoiceCodeNumber != "INV12345" {
+		t.Errorf("expected accepted invoiceCodeNumber to be %s, got %s", "INV12345", accepted.InvoiceCodeNumber)
+	}
+
+	if len(resp.RejectedDocuments) != 1 {
+		t.Fatalf("expected len(rejectedDocuments) to be %d, got %d", 1, len(resp.RejectedDocuments))
+	}
+
+	rejected := resp.RejectedDocuments[0]
+	if rejected.InvoiceCodeNumber != "INV12346" {
+		t.Errorf("expected rejected invoiceCodeNumber to be %s, got %s", "INV12346", rejected.InvoiceCodeNumber)
+	}
+
+	if rejected.Error.ErrorCode != "DS302" {
+		t.Errorf("expected rejected errorCode to be %s, got %s", "DS302", rejected.Error.ErrorCode)
+	}
+}
+
+func TestNewApiEnvironment(t *testing.T) {
+	cases := []struct {
+		label            string
+		opts             []ApiOption
+		expectedApi      string
+		expectedIdentity string
+	}{
+		{label: "default", opts: nil, expectedApi: common.SANDBOX_API_BASE_URL, expectedIdentity: common.SANDBOX_IDENTITY_BASE_URL},
+		{label: "preprod", opts: []ApiOption{WithEnvironment(ENV_PREPROD)}, expectedApi: common.SANDBOX_API_BASE_URL, expectedIdentity: common.SANDBOX_IDENTITY_BASE_URL},
+		{label: "production", opts: []ApiOption{WithEnvironment(ENV_PRODUCTION)}, expectedApi: common.PRODUCTION_API_BASE_URL, expectedIdentity: common.PRODUCTION_IDENTITY_BASE_URL},
+		{
+			label:            "custom",
+			opts:             []ApiOption{WithApiBaseUrl("http://localhost:8080/"), WithIdentityBaseUrl("http://localhost:8081")},
+			expectedApi:      "http://localhost:8080",
+			expectedIdentity: "http://localhost:8081",
+		},
+	}
+
+	for _, test := range cases {
+		api := NewApi("clientId", "clientSecret", test.opts...)
+
+		if api.apiBaseUrl != test.expectedApi {
+			t.Errorf("%s: expected api base URL to be %s, got %s", test.label, test.expectedApi, api.apiBaseUrl)
+		}
+
+		if api.identityBaseUrl != test.expectedIdentity {
+			t.Errorf("%s: expected identity base URL to be %s, got %s", test.label, test.expectedIdentity, api.identityBaseUrl)
+		}
+	}
+}
+
+func TestNewApiUnknownEnvironment(t *testing.T) {
+	var calls int
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer server.Close()
+
+	for _, env := range []Environment{"prod", ""} {
+		api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL), WithEnvironment(env))
+
+		if api.apiBaseUrl != server.URL || api.identityBaseUrl != server.URL {
+			t.Errorf("%q: expected base URLs to be left unchanged, got %s and %s", env, api.apiBaseUrl, api.identityBaseUrl)
+		}
+
+		_, err := api.GetDocumentTypes(context.Background())
+		if !errors.Is(err, ErrUnknownEnvironment) {
+			t.Errorf("%q: expected %v, got %v", env, ErrUnknownEnvironment, err)
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no requests, got %d", calls)
+	}
+}
+
+func TestGetSubmissionUsesApiBaseUrl(t *testing.T) {
+	var requestedPath string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(`{"submissionUid":"HJSD135P2S7D8IU","overallStatus":"valid"}`))
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	resp, err := api.GetSubmission(context.Background(), "HJSD135P2S7D8IU", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedPath := "/api/v1.0/documentsubmissions/HJSD135P2S7D8IU"
+	if requestedPath != expectedPath {
+		t.Errorf("expected request path to be %s, got %s", expectedPath, requestedPath)
+	}
+
+	if resp.OverallStatus != "valid" {
+		t.Errorf("expected overallStatus to be %s, got %s", "valid", resp.OverallStatus)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithHTTPClientTransport(t *testing.T) {
+	var requestedUrls []string
+
+	client := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requestedUrls = append(requestedUrls, req.URL.String())
+
+			body := `{"result":[]}`
+			if req.URL.Path == TAXPAYER_LOGIN_ENDPOINT {
+				body = `{"access_token":"token","expires_in":3600}`
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	api := NewApi("clientId", "clientSecret", WithHTTPClient(client))
+
+	if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedUrls := []string{
+		common.SANDBOX_IDENTITY_BASE_URL + TAXPAYER_LOGIN_ENDPOINT,
+		common.SANDBOX_API_BASE_URL + GET_DOCUMENT_TYPES_ENDPOINT,
+	}
+
+	if len(requestedUrls) != len(expectedUrls) {
+		t.Fatalf("expected %d requests, got %d", len(expectedUrls), len(requestedUrls))
+	}
+
+	for i := range expectedUrls {
+		if requestedUrls[i] != expectedUrls[i] {
+			t.Errorf("expected request %d to be %s, got %s", i, expectedUrls[i], requestedUrls[i])
+		}
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := api.GetDocumentTypes(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+const testRawInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+	<cbc:ID>INV12345</cbc:ID>
+	<cbc:IssueDate>2024-07-01</cbc:IssueDate>
+	<cbc:InvoiceTypeCode listVersionID="1.0">01</cbc:InvoiceTypeCode>
+	<cbc:DocumentCurrencyCode>MYR</cbc:DocumentCurrencyCode>
+	<cac:TaxTotal>
+		<cbc:TaxAmount currencyID="MYR">8.70</cbc:TaxAmount>
+	</cac:TaxTotal>
+	<cac:LegalMonetaryTotal>
+		<cbc:LineExtensionAmount currencyID="MYR">145.00</cbc:LineExtensionAmount>
+		<cbc:PayableAmount currencyID="MYR">153.70</cbc:PayableAmount>
+	</cac:LegalMonetaryTotal>
+	<cac:InvoiceLine>
+		<cbc:ID>1</cbc:ID>
+		<cbc:InvoicedQuantity unitCode="C62">1</cbc:InvoicedQuantity>
+		<cbc:LineExtensionAmount currencyID="MYR">145.00</cbc:LineExtensionAmount>
+		<cac:Item>
+			<cbc:Name>Laptop Peripherals</cbc:Name>
+		</cac:Item>
+		<cac:Price>
+			<cbc:PriceAmount currencyID="MYR">0.29</cbc:PriceAmount>
+		</cac:Price>
+	</cac:InvoiceLine>
+</Invoice>`
+
+func TestGetDocument(t *testing.T) {
+	var requestedPath string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+
+		b, _ := json.Marshal(map[string]string{
+			"uuid":     "F9D425P6DS7D8IU",
+			"status":   "Valid",
+			"document": testRawInvoice,
+		})
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	resp, err := api.GetDocument(context.Background(), "F9D425P6DS7D8IU")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedPath := "/api/v1.0/documents/F9D425P6DS7D8IU/raw"
+	if requestedPath != expectedPath {
+		t.Errorf("expected request path to be %s, got %s", expectedPath, requestedPath)
+	}
+
+	if resp.UUID != "F9D425P6DS7D8IU" || resp.Status != "Valid" {
+		t.Errorf("unexpected document metadata: %+v", resp.SubmissionDocumentSummary)
+	}
+
+	inv, err := resp.Invoice()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.ID != "INV12345" {
+		t.Errorf("expected ID to be %s, got %s", "INV12345", inv.ID)
+	}
+
+	if inv.LegalMonetaryTotal.PayableAmount.Value != 15370 {
+		t.Errorf("expected payable amount to be %d, got %d", 15370, inv.LegalMonetaryTotal.PayableAmount.Value)
+	}
+
+	if inv.LegalMonetaryTotal.PayableAmount.CurrencyID.Code != "MYR" {
+		t.Errorf("expected currency to be %s, got %s", "MYR", inv.LegalMonetaryTotal.PayableAmount.CurrencyID.Code)
+	}
+
+	if len(inv.InvoiceLine) != 1 {
+		t.Fatalf("expected 1 invoice line, got %d", len(inv.InvoiceLine))
+	}
+
+	if inv.InvoiceLine[0].Item.Name != "Laptop Peripherals" {
+		t.Errorf("expected item name to be %s, got %s", "Laptop Peripherals", inv.InvoiceLine[0].Item.Name)
+	}
+
+	if inv.InvoiceLine[0].Price.PriceAmount.Value != 29 {
+		t.Errorf("expected price amount to be %d, got %d", 29, inv.InvoiceLine[0].Price.PriceAmount.Value)
+	}
+}
+
+func TestGetDocumentJSON(t *testing.T) {
+	submitted, err := ubl.ParseInvoice([]byte(testRawInvoice))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	doc, err := NewJSONDocument(submitted)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(doc.Document)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := json.Marshal(map[string]string{
+			"uuid":     "F9D425P6DS7D8IU",
+			"status":   "Valid",
+			"document": "\n" + string(raw),
+		})
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	resp, err := api.GetDocument(context.Background(), "F9D425P6DS7D8IU")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	inv, err := resp.Invoice()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.ID != submitted.ID {
+		t.Errorf("expected ID to be %s, got %s", submitted.ID, inv.ID)
+	}
+
+	if inv.LegalMonetaryTotal.PayableAmount.Value != 15370 || inv.LegalMonetaryTotal.PayableAmount.CurrencyID.Code != "MYR" {
+		t.Errorf("expected payable amount to be 15370 MYR cents, got %d %s", inv.LegalMonetaryTotal.PayableAmount.Value, inv.LegalMonetaryTotal.PayableAmount.CurrencyID.Code)
+	}
+
+	if len(inv.InvoiceLine) != 1 || inv.InvoiceLine[0].Item.Name != "Laptop Peripherals" {
+		t.Fatalf("expected the invoice line to round trip, got %+v", inv.InvoiceLine)
+	}
+
+	again, err := ubl.MarshalInvoiceJSON(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(again) != string(raw) {
+		t.Errorf("expected JSON to round trip\n%s\ngot\n%s", raw, again)
+	}
+
+	resp.Document = "INV12345"
+	if _, err := resp.Invoice(); err == nil {
+		t.Errorf("expected error for a document in neither format")
+	}
+}
+
+func TestGetDocumentDetails(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"uuid": "F9D425P6DS7D8IU",
+			"status": "Invalid",
+			"validationResults": {
+				"status": "Invalid",
+				"validationSteps": [
+					{
+						"status": "Valid",
+						"name": "Step01-Structure Validator"
+					},
+					{
+						"status": "Invalid",
+						"error": {
+							"propertyPath": "Invoice.TaxTotal.TaxAmount",
+							"errorCode": "CF321",
+							"error": "Total tax amount does not match",
+							"innerError": null
+						},
+						"name": "Step06-Taxpayer Validator"
+					}
+				]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	resp, err := api.GetDocumentDetails(context.Background(), "F9D425P6DS7D8IU")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resp.ValidationResults.Status != "Invalid" {
+		t.Errorf("expected validation status to be %s, got %s", "Invalid", resp.ValidationResults.Status)
+	}
+
+	failed := resp.ValidationResults.FailedSteps()
+	if len(failed) != 1 {
+		t.Fatalf("expected 1 failed step, got %d", len(failed))
+	}
+
+	if failed[0].Name != "Step06-Taxpayer Validator" {
+		t.Errorf("expected failed step to be %s, got %s", "Step06-Taxpayer Validator", failed[0].Name)
+	}
+
+	if failed[0].Error == nil || failed[0].Error.ErrorCode != "CF321" {
+		t.Errorf("expected error code CF321, got %+v", failed[0].Error)
+	}
+}
+
+func TestBuildSearchDocumentsRequestFull(t *testing.T) {
+	uuid := "F9D425P6DS7D8IU"
+	submissionDateFrom := "2024-01-01T00:00:00Z"
+	submissionDateTo := "2024-01-31T23:59:59Z"
+	issueDateFrom := "2024-01-01T00:00:00Z"
+	issueDateTo := "2024-01-31T23:59:59Z"
+	invoiceDirection := "Received"
+	status := "Valid"
+	documentType := "01"
+	searchQuery := "searchQueryValue"
+	receiverId := "receiverIdValue"
+	receiverIdType := "receiverIdTypeValue"
+	receiverTin := "receiverTinValue"
+	issuerTin := "issuerTinValue"
+	issuerId := "issuerIdValue"
+	issuerIdType := "issuerIdTypeValue"
+
+	query := SearchDocumentsQuery{
+		Uuid:               &uuid,
+		SubmissionDateFrom: &submissionDateFrom,
+		SubmissionDateTo:   &submissionDateTo,
+		PageSize:           50,
+		PageNo:             2,
+		IssueDateFrom:      &issueDateFrom,
+		IssueDateTo:        &issueDateTo,
+		InvoiceDirection:   &invoiceDirection,
+		Status:             &status,
+		DocumentType:       &documentType,
+		SearchQuery:        &searchQuery,
+		ReceiverId:         &receiverId,
+		ReceiverIdType:     &receiverIdType,
+		ReceiverTin:        &receiverTin,
+		IssuerTin:          &issuerTin,
+		IssuerId:           &issuerId,
+		IssuerIdType:       &issuerIdType,
+	}
+	req, err := buildSearchDocumentsRequest(context.Background(), common.SANDBOX_API_BASE_URL, &query)
+	if err != nil {
+		t.Fatalf("unexpected error when building request: %s", err)
+	}
+
+	if req.URL.Path != SEARCH_DOCUMENTS_ENDPOINT {
+		t.Errorf("expected path to be %s, got %s", SEARCH_DOCUMENTS_ENDPOINT, req.URL.Path)
+	}
+
+	parsed, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		t.Fatalf("unexpected error when parsing URL query: %s", err)
+	}
+
+	cases := []struct {
+		label    string
+		expected string
+	}{
+		{label: "uuid", expected: uuid},
+		{label: "submissionDateFrom", expected: submissionDateFrom},
+		{label: "submissionDateTo", expected: submissionDateTo},
+		{label: "pageSize", expected: "50"},
+		{label: "pageNo", expected: "2"},
+		{label: "issueDateFrom", expected: issueDateFrom},
+		{label: "issueDateTo", expected: issueDateTo},
+		{label: "invoiceDirection", expected: invoiceDirection},
+		{label: "status", expected: status},
+		{label: "documentType", expected: documentType},
+		{label: "searchQuery", expected: searchQuery},
+		{label: "receiverId", expected: receiverId},
+		{label: "receiverIdType", expected: receiverIdType},
+		{label: "receiverTin", expected: receiverTin},
+		{label: "issuerTin", expected: issuerTin},
+		{label: "issuerId", expected: issuerId},
+		{label: "issuerIdType", expected: issuerIdType},
+	}
+
+	for _, test := range cases {
+		if actual := parsed.Get(test.label); actual != test.expected {
+			t.Errorf("expected %s to be %q, got %q", test.label, test.expected, actual)
+		}
+	}
+}
+
+func TestSearchDocumentsIterator(t *testing.T) {
+	var pageSizes []string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageSizes = append(pageSizes, r.URL.Query().Get("pageSize"))
+
+		switch r.URL.Query().Get("pageNo") {
+		case "1":
+			w.Write([]byte(`{"result":[{"uuid":"A","totalPayableAmount":10.10},{"uuid":"B"}],"metadata":{"totalPages":3,"totalCount":5}}`))
+		case "2":
+			w.Write([]byte(`{"result":[{"uuid":"C"},{"uuid":"D"}],"metadata":{"totalPages":"3","totalCount":"5"}}`))
+		case "3":
+			w.Write([]byte(`{"result":[{"uuid":"E"}],"metadata":{"totalPages":3,"totalCount":5}}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("pageNo"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	it := NewSearchDocumentsIterator(api, SearchDocumentsQuery{PageSize: 1000})
+
+	var uuids []string
+	for it.Next(context.Background()) {
+		uuids = append(uuids, it.Document().UUID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Join(uuids, ",") != "A,B,C,D,E" {
+		t.Errorf("expected documents A,B,C,D,E, got %s", strings.Join(uuids, ","))
+	}
+
+	for _, size := range pageSizes {
+		if size != "100" {
+			t.Errorf("expected page size to be capped at 100, got %s", size)
+		}
+	}
+}
+
+func TestSearchDocumentsIteratorError(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pageNo") == "1" {
+			w.Write([]byte(`{"result":[{"uuid":"A"}],"metadata":{"totalPages":2,"totalCount":2}}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	it := NewSearchDocumentsIterator(api, SearchDocumentsQuery{})
+
+	count := 0
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	if count != 1 {
+		t.Errorf("expected 1 document before the error, got %d", count)
+	}
+
+	if !IsValidationError(it.Err()) {
+		t.Errorf("expected validation error, got %v", it.Err())
+	}
+}
+
+func TestGetRecentDocuments(t *testing.T) {
+	var authHeader string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Write([]byte(`{
+			"result": [
+				{"uuid": "42S512YACQBRSRHYKBXBTGQG22", "status": "Valid", "total": 124.09},
+				{"uuid": "42S512YACQBRSRHYKBXBTGQG23", "status": "Cancelled", "total": 50.00}
+			],
+			"metadata": {"totalPages": 1, "totalCount": 2}
+		}`))
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	resp, err := api.GetRecentDocuments(context.Background(), &GetRecentDocumentsQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if authHeader != "Bearer token-1" {
+		t.Errorf("expected Authorization header to be %s, got %s", "Bearer token-1", authHeader)
+	}
+
+	if len(resp.Result) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(resp.Result))
+	}
+
+	if resp.Result[0].Total.String() != "124.09" {
+		t.Errorf("expected total to be %s, got %s", "124.09", resp.Result[0].Total)
+	}
+
+	if resp.Metadata.TotalPages != 1 || resp.Metadata.TotalCount != 2 {
+		t.Errorf("unexpected metadata: %+v", resp.Metadata)
+	}
+}
+
+func TestRecentDocumentsIterator(t *testing.T) {
+	var pageNos []string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageNos = append(pageNos, r.URL.Query().Get("pageNo"))
+
+		if r.URL.Query().Get("pageSize") != "100" {
+			t.Errorf("expected page size to be 100, got %s", r.URL.Query().Get("pageSize"))
+		}
+
+		switch r.URL.Query().Get("pageNo") {
+		case "2":
+			// counts are sent as strings
+			w.Write([]byte(`{"result":[{"uuid":"A"},{"uuid":"B"}],"metadata":{"totalPages":"3","totalCount":"5"}}`))
+		case "3":
+			w.Write([]byte(`{"result":[{"uuid":"C"}],"metadata":{"totalPages":"3","totalCount":"5"}}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("pageNo"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	it := NewRecentDocumentsIterator(api, GetRecentDocumentsQuery{PageNo: 2})
+
+	var uuids []string
+	for it.Next(context.Background()) {
+		uuids = append(uuids, it.Document().UUID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Join(uuids, ",") != "A,B,C" {
+		t.Errorf("expected documents A,B,C, got %s", strings.Join(uuids, ","))
+	}
+
+	if strings.Join(pageNos, ",") != "2,3" {
+		t.Errorf("expected pages 2,3 to be requested, got %s", strings.Join(pageNos, ","))
+	}
+
+	if it.Next(context.Background()) {
+		t.Errorf("expected iterator to stay exhausted")
+	}
+}
+
+// format like the API does, or empty for the zero time
+func formatActiveTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,174 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request headers whose values never reach an audit hook.
+var auditRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Body fields redacted by AuditRecord.Redacted, in both JSON and form bodies.
+var auditRedactedFields = []string{"client_secret", "access_token", "refresh_token", "id_token"}
+
+var (
+	redactedJSONField = regexp.MustCompile(`("(?:` + strings.Join(auditRedactedFields, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	redactedFormField = regexp.MustCompile(`(^|&)((?:` + strings.Join(auditRedactedFields, "|") + `)=)[^&]*`)
+)
+
+const redacted = "REDACTED"
+
+// AuditRecord is the evidence of a single HTTP exchange with the platform.
+// Retries are recorded separately, one record per attempt.
+type AuditRecord struct {
+	Time           time.Time     `json:"time"`       // when the request was sent
+	Endpoint       string        `json:"endpoint"`   // endpoint constant, e.g. SUBMIT_DOCUMENT_ENDPOINT
+	Attempt        int           `json:"attempt"`    // 1 for the first attempt, incremented on every retry
+	OnBehalfOf     string        `json:"onBehalfOf"` // TIN of the taxpayer an intermediary acts for, empty otherwise
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"requestHeader"` // credentials are always redacted
+	RequestBody    string        `json:"requestBody"`
+	StatusCode     int           `json:"statusCode"` // 0 if no response was received
+	ResponseHeader http.Header   `json:"responseHeader"`
+	ResponseBody   string        `json:"responseBody"`
+	Latency        time.Duration `json:"latency"`       // encoded in nanoseconds
+	CorrelationID  string        `json:"correlationId"` // empty if the platform did not send one
+	Error          string        `json:"error"`         // transport error, if no response was received
+}
+
+// Called after every HTTP exchange with the platform. Hooks are called
+// synchronously and must not modify the record.
+type AuditHook func(ctx context.Context, record *AuditRecord)
+
+// Hand every HTTP exchange with the platform to hook, e.g. to retain
+// evidence for tax audits. The header values in records are redacted, but
+// the bodies are not; use AuditRecord.Redacted before persisting them.
+func WithAuditHook(hook AuditHook) ApiOption {
+	return func(a *Api) {
+		a.auditHook = hook
+	}
+}
+
+func (a *Api) audit(endpoint string, req *http.Request, attempt int, sentAt time.Time, latency time.Duration, resp *http.Response, respBytes []byte, err error) {
+	record := AuditRecord{
+		Time:          sentAt,
+		Endpoint:      endpoint,
+		Attempt:       attempt,
+		OnBehalfOf:    a.onBehalfOf,
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: req.Header.Clone(),
+		Latency:       latency,
+	}
+
+	for _, key := range auditRedactedHeaders {
+		if record.RequestHeader.Get(key) != "" {
+			record.RequestHeader.Set(key, redacted)
+		}
+	}
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(body)
+			record.RequestBody = string(b)
+		}
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.StatusCode = resp.StatusCode
+		record.ResponseHeader = resp.Header.Clone()
+		record.ResponseBody = string(respBytes)
+		record.CorrelationID = correlationID(resp)
+	}
+
+	a.auditHook(req.Context(), &record)
+}
+
+// A copy of the record with the client secret and tokens in the request and
+// response bodies replaced by "REDACTED".
+func (r *AuditRecord) Redacted() *AuditRecord {
+	c := *r
+	c.RequestBody = redactBody(r.RequestBody)
+	c.ResponseBody = redactBody(r.ResponseBody)
+
+	return &c
+}
+
+func redactBody(body string) string {
+	body = redactedJSONField.ReplaceAllString(body, `${1}"`+redacted+`"`)
+	body = redactedFormField.ReplaceAllString(body, `${1}${2}`+redacted)
+
+	return body
+}
+
+// JSONLinesAuditSink writes every record, redacted, as a line of JSON.
+// Pass its Record method to WithAuditHook. It is safe for concurrent use.
+type JSONLinesAuditSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	err    error
+}
+
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+// Append records to the file at path, creating it if needed. Close the sink
+// when done.
+func OpenAuditLog(path string) (*JSONLinesAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLinesAuditSink{w: f, closer: f}, nil
+}
+
+// Record writes the redacted record. The first write error is kept and
+// returned by Err, since hooks cannot fail the call they observe.
+func (s *JSONLinesAuditSink) Record(ctx context.Context, record *AuditRecord) {
+	b, err := json.Marshal(record.Redacted())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		_, err = s.w.Write(append(b, '\n'))
+	}
+
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// The first error encountered while writing records, if any.
+func (s *JSONLinesAuditSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close the file opened by OpenAuditLog. Does nothing for sinks created
+// with NewJSONLinesAuditSink.
+func (s *JSONLinesAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer.Close()
+}
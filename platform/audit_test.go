@@ -0,0 +1,136 @@
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditHook(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("correlationId", "corr-1")
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer server.Close()
+
+	var records []*AuditRecord
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL),
+		WithAuditHook(func(ctx context.Context, record *AuditRecord) {
+			records = append(records, record)
+		}),
+	)
+
+	if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	login, call := records[0], records[1]
+
+	if login.Endpoint != TAXPAYER_LOGIN_ENDPOINT || !strings.Contains(login.RequestBody, "client_secret=clientSecret") {
+		t.Errorf("expected login record with the raw request body, got %s %q", login.Endpoint, login.RequestBody)
+	}
+
+	if call.Endpoint != GET_DOCUMENT_TYPES_ENDPOINT || call.Method != http.MethodGet || call.URL != server.URL+GET_DOCUMENT_TYPES_ENDPOINT {
+		t.Errorf("unexpected record %s %s %s", call.Endpoint, call.Method, call.URL)
+	}
+
+	if call.RequestHeader.Get("Authorization") != "REDACTED" {
+		t.Errorf("expected Authorization to be redacted, got %s", call.RequestHeader.Get("Authorization"))
+	}
+
+	if call.StatusCode != http.StatusOK || call.ResponseBody != `{"result":[]}` || call.CorrelationID != "corr-1" || call.Attempt != 1 {
+		t.Errorf("unexpected record %+v", call)
+	}
+}
+
+func TestAuditRecordRedacted(t *testing.T) {
+	record := AuditRecord{
+		RequestBody:  "client_id=clientId&client_secret=s3cr%26t&grant_type=client_credentials",
+		ResponseBody: `{"access_token": "eyJ\"abc","expires_in":3600,"token_type":"Bearer"}`,
+	}
+
+	redacted := record.Redacted()
+
+	expectedRequest := "client_id=clientId&client_secret=REDACTED&grant_type=client_credentials"
+	if redacted.RequestBody != expectedRequest {
+		t.Errorf("expected request body to be %s, got %s", expectedRequest, redacted.RequestBody)
+	}
+
+	expectedResponse := `{"access_token": "REDACTED","expires_in":3600,"token_type":"Bearer"}`
+	if redacted.ResponseBody != expectedResponse {
+		t.Errorf("expected response body to be %s, got %s", expectedResponse, redacted.ResponseBody)
+	}
+
+	if record.RequestBody == redacted.RequestBody {
+		t.Errorf("expected the original record to be left untouched")
+	}
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL), WithAuditHook(sink.Record))
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := sink.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, secret := range []string{"clientSecret", "token-1"} {
+		if bytes.Contains(b, []byte(secret)) {
+			t.Errorf("expected %s to be redacted from the audit log", secret)
+		}
+	}
+
+	var endpoints []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit line %s: %s", scanner.Text(), err)
+		}
+
+		endpoints = append(endpoints, record.Endpoint)
+	}
+
+	expected := []string{TAXPAYER_LOGIN_ENDPOINT, GET_DOCUMENT_TYPES_ENDPOINT, GET_DOCUMENT_TYPES_ENDPOINT}
+	if strings.Join(endpoints, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected records for %v, got %v", expected, endpoints)
+	}
+}
@@ -0,0 +1,156 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/programmer-my/einvoice-go/common"
+)
+
+// Submission limits enforced by the platform
+// Reference: https://sdk.myinvois.hasil.gov.my/einvoicingapi/02-submit-documents/
+const (
+	MAX_DOCUMENTS_PER_SUBMISSION = 100
+	MAX_SUBMISSION_SIZE          = 5 * 1024 * 1024 // size of the request payload
+	MAX_DOCUMENT_SIZE            = 300 * 1024      // size of a single raw document
+)
+
+// size of `{"documents":[]}` wrapping every submission
+const submissionEnvelopeSize = len(`{"documents":[]}`)
+
+// Returned when a single document can never fit into a submission.
+type DocumentTooLargeError struct {
+	CodeNumber string
+	Size       int // in bytes
+	Limit      int // in bytes
+}
+
+func (e *DocumentTooLargeError) Error() string {
+	return fmt.Sprintf("document %s is %d bytes, exceeding the limit of %d bytes", e.CodeNumber, e.Size, e.Limit)
+}
+
+type BatchAcceptedDocument struct {
+	SubmissionUID string
+	UUID          string
+}
+
+type BatchRejectedDocument struct {
+	SubmissionUID string
+	Error         common.ErrResponse
+}
+
+type BatchSubmitResult struct {
+	SubmissionUIDs []string                         // in the order the submissions were made
+	Accepted       map[string]BatchAcceptedDocument // keyed by codeNumber
+	Rejected       map[string]BatchRejectedDocument // keyed by codeNumber
+}
+
+// BatchSubmitter splits any number of documents into submissions that
+// comply with the platform limits and submits them one after another.
+type BatchSubmitter struct {
+	api *Api
+}
+
+func NewBatchSubmitter(api *Api) *BatchSubmitter {
+	return &BatchSubmitter{api: api}
+}
+
+// Submit all documents, in as many submissions as needed.
+//
+// Oversized documents are rejected before anything is sent. If one of the
+// submissions fails, the results collected so far are returned along with
+// the error, so callers know which documents already reached the platform.
+func (b *BatchSubmitter) Submit(ctx context.Context, docs []Document) (*BatchSubmitResult, error) {
+	batches, err := SplitDocuments(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := BatchSubmitResult{
+		Accepted: make(map[string]BatchAcceptedDocument),
+		Rejected: make(map[string]BatchRejectedDocument),
+	}
+
+	for i, batch := range batches {
+		resp, err := b.api.SubmitDocument(ctx, batch)
+		if err != nil {
+			return &result, fmt.Errorf("submission %d of %d failed: %w", i+1, len(batches), err)
+		}
+
+		result.SubmissionUIDs = append(result.SubmissionUIDs, resp.SubmissionUID)
+
+		for _, accepted := range resp.AcceptedDocuments {
+			result.Accepted[accepted.InvoiceCodeNumber] = BatchAcceptedDocument{
+				SubmissionUID: resp.SubmissionUID,
+				UUID:          accepted.UUID,
+			}
+		}
+
+		for _, rejected := range resp.RejectedDocuments {
+			result.Rejected[rejected.InvoiceCodeNumber] = BatchRejectedDocument{
+				SubmissionUID: resp.SubmissionUID,
+				Error:         rejected.Error,
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+// SplitDocuments groups documents into batches that each fit into a single
+// submission, preserving their order.
+func SplitDocuments(docs []Document) ([][]Document, error) {
+	seen := make(map[string]bool, len(docs))
+
+	var batches [][]Document
+	var current []Document
+	currentSize := submissionEnvelopeSize
+
+	for _, doc := range docs {
+		if seen[doc.CodeNumber] {
+			return nil, fmt.Errorf("duplicate codeNumber %s", doc.CodeNumber)
+		}
+
+		seen[doc.CodeNumber] = true
+
+		if size := rawDocumentSize(doc); size > MAX_DOCUMENT_SIZE {
+			return nil, &DocumentTooLargeError{CodeNumber: doc.CodeNumber, Size: size, Limit: MAX_DOCUMENT_SIZE}
+		}
+
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		// +1 for the separating comma
+		size := len(b) + 1
+		if submissionEnvelopeSize+size > MAX_SUBMISSION_SIZE {
+			return nil, &DocumentTooLargeError{CodeNumber: doc.CodeNumber, Size: size, Limit: MAX_SUBMISSION_SIZE - submissionEnvelopeSize}
+		}
+
+		if len(current) == MAX_DOCUMENTS_PER_SUBMISSION || currentSize+size > MAX_SUBMISSION_SIZE {
+			batches = append(batches, current)
+			current = nil
+			currentSize = submissionEnvelopeSize
+		}
+
+		current = append(current, doc)
+		currentSize += size
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches, nil
+}
+
+// size of the raw document before base64 encoding
+func rawDocumentSize(doc Document) int {
+	encoded := doc.Document
+	padding := len(encoded) - len(strings.TrimRight(encoded, "="))
+
+	return len(encoded)/4*3 - padding
+}
@@ -0,0 +1,238 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/programmer-my/einvoice-go/common"
+)
+
+func TestSplitDocumentsByCount(t *testing.T) {
+	var docs []Document
+	for i := 0; i < 250; i++ {
+		docs = append(docs, NewDocument(FORMAT_XML, fmt.Sprintf("INV-%04d", i), []byte("<Invoice/>")))
+	}
+
+	batches, err := SplitDocuments(docs)
+	if err != nil {
+		t.Fatalf("unexpected error when splitting documents: %s", err)
+	}
+
+	expectedSizes := []int{100, 100, 50}
+
+	if len(batches) != len(expectedSizes) {
+		t.Fatalf("expected %d batches, got %d", len(expectedSizes), len(batches))
+	}
+
+	for i, batch := range batches {
+		if len(batch) != expectedSizes[i] {
+			t.Errorf("expected batch %d to have %d documents, got %d", i, expectedSizes[i], len(batch))
+		}
+	}
+
+	if batches[1][0].CodeNumber != "INV-0100" {
+		t.Errorf("expected batch 1 to start with %s, got %s", "INV-0100", batches[1][0].CodeNumber)
+	}
+}
+
+func TestSplitDocumentsBySize(t *testing.T) {
+	// each document is ~267KB once base64 encoded, so only 19 fit into 5MB
+	raw := bytes.Repeat([]byte("a"), 200*1024)
+
+	var docs []Document
+	for i := 0; i < 40; i++ {
+		docs = append(docs, NewDocument(FORMAT_XML, fmt.Sprintf("INV-%04d", i), raw))
+	}
+
+	batches, err := SplitDocuments(docs)
+	if err != nil {
+		t.Fatalf("unexpected error when splitting documents: %s", err)
+	}
+
+	expectedSizes := []int{19, 19, 2}
+
+	if len(batches) != len(expectedSizes) {
+		t.Fatalf("expected %d batches, got %d", len(expectedSizes), len(batches))
+	}
+
+	for i, batch := range batches {
+		if len(batch) != expectedSizes[i] {
+			t.Errorf("expected batch %d to have %d documents, got %d", i, expectedSizes[i], len(batch))
+		}
+	}
+}
+
+func TestSplitDocumentsTooLarge(t *testing.T) {
+	docs := []Document{
+		NewDocument(FORMAT_XML, "INV-0001", []byte("<Invoice/>")),
+		NewDocument(FORMAT_XML, "INV-0002", bytes.Repeat([]byte("a"), MAX_DOCUMENT_SIZE+1)),
+	}
+
+	_, err := SplitDocuments(docs)
+
+	var tooLarge *DocumentTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected DocumentTooLargeError, got %v", err)
+	}
+
+	if tooLarge.CodeNumber != "INV-0002" {
+		t.Errorf("expected codeNumber to be %s, got %s", "INV-0002", tooLarge.CodeNumber)
+	}
+
+	if tooLarge.Size != MAX_DOCUMENT_SIZE+1 {
+		t.Errorf("expected size to be %d, got %d", MAX_DOCUMENT_SIZE+1, tooLarge.Size)
+	}
+}
+
+func TestSplitDocumentsDuplicateCodeNumber(t *testing.T) {
+	docs := []Document{
+		NewDocument(FORMAT_XML, "INV-0001", []byte("<Invoice/>")),
+		NewDocument(FORMAT_XML, "INV-0001", []byte("<Invoice/>")),
+	}
+
+	if _, err := SplitDocuments(docs); err == nil {
+		t.Errorf("expected error for duplicate codeNumber, got nil")
+	}
+}
+
+// newBatchTestApi accepts every document except rejected ones, and fails
+// the submission with the given number, if any, with a 500.
+func newBatchTestApi(t *testing.T, rejected string, failing int) (*Api, *int) {
+	submissions := 0
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		submissions++
+
+		if submissions == failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var req SubmitDocumentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode submission: %s", err)
+		}
+
+		if len(req.Documents) > MAX_DOCUMENTS_PER_SUBMISSION {
+			t.Errorf("expected at most %d documents per submission, got %d", MAX_DOCUMENTS_PER_SUBMISSION, len(req.Documents))
+		}
+
+		resp := SubmitDocumentResponse{SubmissionUID: fmt.Sprintf("SUB-%d", submissions)}
+		for _, doc := range req.Documents {
+			if doc.CodeNumber == rejected {
+				resp.RejectedDocuments = append(resp.RejectedDocuments, RejectedDocuments{
+					InvoiceCodeNumber: doc.CodeNumber,
+					Error:             common.ErrResponse{ErrorCode: "DS302", ErrorMessage: "Duplicate submission"},
+				})
+				continue
+			}
+
+			resp.AcceptedDocuments = append(resp.AcceptedDocuments, AcceptedDocuments{
+				UUID:              "UUID-" + doc.CodeNumber,
+				InvoiceCodeNumber: doc.CodeNumber,
+			})
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	return api, &submissions
+}
+
+func newBatchTestDocuments(n int) []Document {
+	var docs []Document
+	for i := 0; i < n; i++ {
+		docs = append(docs, NewDocument(FORMAT_XML, fmt.Sprintf("INV-%04d", i), []byte("<Invoice/>")))
+	}
+
+	return docs
+}
+
+func TestBatchSubmitterSubmit(t *testing.T) {
+	api, submissions := newBatchTestApi(t, "INV-0150", 0)
+
+	result, err := NewBatchSubmitter(api).Submit(context.Background(), newBatchTestDocuments(250))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *submissions != 3 {
+		t.Errorf("expected %d submissions, got %d", 3, *submissions)
+	}
+
+	if fmt.Sprint(result.SubmissionUIDs) != "[SUB-1 SUB-2 SUB-3]" {
+		t.Errorf("expected submission UIDs SUB-1 SUB-2 SUB-3, got %v", result.SubmissionUIDs)
+	}
+
+	if len(result.Accepted) != 249 || len(result.Rejected) != 1 {
+		t.Fatalf("expected 249 accepted and 1 rejected documents, got %d and %d", len(result.Accepted), len(result.Rejected))
+	}
+
+	for i := 0; i < 250; i++ {
+		codeNumber := fmt.Sprintf("INV-%04d", i)
+		expectedUID := fmt.Sprintf("SUB-%d", i/MAX_DOCUMENTS_PER_SUBMISSION+1)
+
+		if codeNumber == "INV-0150" {
+			rejected := result.Rejected[codeNumber]
+			if rejected.SubmissionUID != expectedUID || rejected.Error.ErrorCode != "DS302" {
+				t.Errorf("expected %s to be rejected in %s with DS302, got %+v", codeNumber, expectedUID, rejected)
+			}
+			continue
+		}
+
+		accepted, ok := result.Accepted[codeNumber]
+		if !ok {
+			t.Errorf("expected %s to be accepted", codeNumber)
+			continue
+		}
+
+		if accepted.SubmissionUID != expectedUID || accepted.UUID != "UUID-"+codeNumber {
+			t.Errorf("expected %s to be accepted in %s as UUID-%s, got %+v", codeNumber, expectedUID, codeNumber, accepted)
+		}
+	}
+}
+
+func TestBatchSubmitterPartialFailure(t *testing.T) {
+	api, submissions := newBatchTestApi(t, "", 2)
+
+	result, err := NewBatchSubmitter(api).Submit(context.Background(), newBatchTestDocuments(250))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected APIError with status 500, got %v", err)
+	}
+
+	// submissions are not retried, as the platform may have accepted them
+	if *submissions != 2 {
+		t.Errorf("expected %d submissions, got %d", 2, *submissions)
+	}
+
+	if result == nil {
+		t.Fatalf("expected the results of the first submission")
+	}
+
+	if fmt.Sprint(result.SubmissionUIDs) != "[SUB-1]" {
+		t.Errorf("expected submission UIDs SUB-1, got %v", result.SubmissionUIDs)
+	}
+
+	if len(result.Accepted) != MAX_DOCUMENTS_PER_SUBMISSION || len(result.Rejected) != 0 {
+		t.Errorf("expected %d accepted and no rejected documents, got %d and %d", MAX_DOCUMENTS_PER_SUBMISSION, len(result.Accepted), len(result.Rejected))
+	}
+
+	if _, ok := result.Accepted["INV-0099"]; !ok {
+		t.Errorf("expected INV-0099 of the first submission to be accepted")
+	}
+
+	if _, ok := result.Accepted["INV-0100"]; ok {
+		t.Errorf("expected INV-0100 of the failed submission not to be accepted")
+	}
+}
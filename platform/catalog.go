@@ -0,0 +1,146 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNoActiveVersion = errors.New("no active document type version")
+
+// The version as used in documents, e.g. "1.1".
+func (v *DocumentTypeVersion) Version() string {
+	return strconv.FormatFloat(float64(v.VersionNumber), 'f', 1, 32)
+}
+
+// Whether the version is published and active at t.
+func (v *DocumentTypeVersion) IsActiveAt(t time.Time) bool {
+	if !strings.EqualFold(v.Status, "published") {
+		return false
+	}
+
+	if t.Before(v.ActiveFrom) {
+		return false
+	}
+
+	return v.ActiveTo.IsZero() || t.Before(v.ActiveTo)
+}
+
+// DocumentTypeCatalog caches the document types and their versions, and
+// resolves which version to use for a document.
+//
+// It is safe for concurrent use.
+type DocumentTypeCatalog struct {
+	api *Api
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	types     []DocumentType
+	fetchedAt time.Time
+}
+
+// Cache the document types for ttl, or a day if ttl is zero. They rarely
+// change.
+func NewDocumentTypeCatalog(api *Api, ttl time.Duration) *DocumentTypeCatalog {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return &DocumentTypeCatalog{
+		api: api,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// DocumentTypes returns every document type, fetching them if the cache
+// is empty or stale. The result is a copy, free to be modified.
+func (c *DocumentTypeCatalog) DocumentTypes(ctx context.Context) ([]DocumentType, error) {
+	types, err := c.cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make([]DocumentType, len(types))
+	for i, t := range types {
+		t.Versions = append([]DocumentTypeVersion(nil), t.Versions...)
+		copied[i] = t
+	}
+
+	return copied, nil
+}
+
+// The cached document types, which must not be modified. A refresh
+// replaces the slice rather than changing it.
+func (c *DocumentTypeCatalog) cached(ctx context.Context) ([]DocumentType, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.types != nil && c.now().Before(c.fetchedAt.Add(c.ttl)) {
+		return c.types, nil
+	}
+
+	resp, err := c.api.GetDocumentTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.types = resp.Result
+	c.fetchedAt = c.now()
+
+	return c.types, nil
+}
+
+// ActiveVersion returns the latest version of the document type with the
+// given invoice type code that is active at issuedAt.
+func (c *DocumentTypeCatalog) ActiveVersion(ctx context.Context, invoiceTypeCode int, issuedAt time.Time) (DocumentTypeVersion, error) {
+	types, err := c.cached(ctx)
+	if err != nil {
+		return DocumentTypeVersion{}, err
+	}
+
+	var active *DocumentTypeVersion
+
+	for i := range types {
+		if types[i].InvoiceTypeCode != invoiceTypeCode {
+			continue
+		}
+
+		for j := range types[i].Versions {
+			version := &types[i].Versions[j]
+
+			if version.IsActiveAt(issuedAt) && (active == nil || version.VersionNumber > active.VersionNumber) {
+				active = version
+			}
+		}
+	}
+
+	if active == nil {
+		return DocumentTypeVersion{}, fmt.Errorf("%w for invoice type code %02d at %s", ErrNoActiveVersion, invoiceTypeCode, issuedAt.Format(time.RFC3339))
+	}
+
+	return *active, nil
+}
+
+// ResolveVersion returns the version, e.g. "1.1", to use for a document with
+// the given invoice type code, e.g. "01", issued at issuedAt.
+//
+// It satisfies document.VersionResolver.
+func (c *DocumentTypeCatalog) ResolveVersion(ctx context.Context, invoiceTypeCode string, issuedAt time.Time) (string, error) {
+	code, err := strconv.Atoi(invoiceTypeCode)
+	if err != nil {
+		return "", fmt.Errorf("invalid invoice type code %q: %w", invoiceTypeCode, err)
+	}
+
+	version, err := c.ActiveVersion(ctx, code, issuedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Version(), nil
+}
@@ -0,0 +1,136 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testDocumentTypes = `{"result":[
+	{"id":45,"invoiceTypeCode":1,"description":"Invoice","activeFrom":"2015-02-13T13:15:00Z","documentTypeVersions":[
+		{"id":454,"name":"1.0","activeFrom":"2015-02-13T13:15:00Z","versionNumber":1.0,"status":"published"},
+		{"id":455,"name":"1.1","activeFrom":"2024-07-01T00:00:00Z","versionNumber":1.1,"status":"published"},
+		{"id":456,"name":"1.2","activeFrom":"2024-07-01T00:00:00Z","versionNumber":1.2,"status":"draft"}
+	]},
+	{"id":46,"invoiceTypeCode":2,"description":"Credit Note","activeFrom":"2015-02-13T13:15:00Z","documentTypeVersions":[
+		{"id":460,"name":"1.0","activeFrom":"2015-02-13T13:15:00Z","activeTo":"2024-07-01T00:00:00Z","versionNumber":1.0,"status":"published"}
+	]}
+]}`
+
+func newCatalogTestApi(t *testing.T, calls *int64) *Api {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+		w.Write([]byte(testDocumentTypes))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+}
+
+func TestDocumentTypeCatalogResolveVersion(t *testing.T) {
+	var calls int64
+	catalog := NewDocumentTypeCatalog(newCatalogTestApi(t, &calls), 0)
+
+	tests := []struct {
+		typeCode string
+		issuedAt time.Time
+		expected string
+	}{
+		{"01", time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC), "1.0"},
+		{"01", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), "1.1"},
+		{"1", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "1.1"},
+		{"02", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), "1.0"},
+	}
+
+	for _, test := range tests {
+		version, err := catalog.ResolveVersion(context.Background(), test.typeCode, test.issuedAt)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if version != test.expected {
+			t.Errorf("expected version of %s at %s to be %s, got %s", test.typeCode, test.issuedAt, test.expected, version)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected document types to be fetched once, got %d", calls)
+	}
+}
+
+func TestDocumentTypeCatalogNoActiveVersion(t *testing.T) {
+	var calls int64
+	catalog := NewDocumentTypeCatalog(newCatalogTestApi(t, &calls), 0)
+
+	for _, typeCode := range []string{"02", "11"} {
+		_, err := catalog.ResolveVersion(context.Background(), typeCode, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+		if !errors.Is(err, ErrNoActiveVersion) {
+			t.Errorf("expected %v for %s, got %v", ErrNoActiveVersion, typeCode, err)
+		}
+	}
+
+	if _, err := catalog.ResolveVersion(context.Background(), "invoice", time.Now()); err == nil {
+		t.Errorf("expected error for invalid invoice type code")
+	}
+}
+
+func TestDocumentTypeCatalogExpires(t *testing.T) {
+	var calls int64
+	catalog := NewDocumentTypeCatalog(newCatalogTestApi(t, &calls), time.Hour)
+
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	catalog.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := catalog.DocumentTypes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected document types to be fetched once, got %d", calls)
+	}
+
+	now = now.Add(time.Hour)
+
+	if _, err := catalog.DocumentTypes(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected document types to be fetched again after the ttl, got %d", calls)
+	}
+}
+
+func TestDocumentTypeCatalogReturnsCopies(t *testing.T) {
+	var calls int64
+	catalog := NewDocumentTypeCatalog(newCatalogTestApi(t, &calls), 0)
+	issuedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	types, err := catalog.DocumentTypes(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	types[0].InvoiceTypeCode = 99
+	types[0].Versions[1].Status = "deactivated"
+
+	version, err := catalog.ActiveVersion(context.Background(), 1, issuedAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	version.VersionNumber = 9
+
+	resolved, err := catalog.ResolveVersion(context.Background(), "01", issuedAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resolved != "1.1" {
+		t.Errorf("expected version to be 1.1, got %s", resolved)
+	}
+}
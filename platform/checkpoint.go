@@ -0,0 +1,157 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CheckpointStore persists how far a background consumer got, so it can
+// resume after a restart. Checkpoints are opaque bytes identified by a key,
+// one per consumer.
+type CheckpointStore interface {
+	// Load returns the checkpoint saved under key, or nil if there is none.
+	Load(ctx context.Context, key string) ([]byte, error)
+
+	// Save replaces the checkpoint saved under key.
+	Save(ctx context.Context, key string, checkpoint []byte) error
+}
+
+// Load the checkpoint saved under key into v. Returns false if there is none.
+func LoadCheckpoint(ctx context.Context, store CheckpointStore, key string, v any) (bool, error) {
+	b, err := store.Load(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	// an empty checkpoint, e.g. a file truncated by a crash, is no checkpoint
+	if len(b) == 0 {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, fmt.Errorf("invalid checkpoint %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Save v as the checkpoint under key.
+func SaveCheckpoint(ctx context.Context, store CheckpointStore, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return store.Save(ctx, key, b)
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory, for tests and for
+// consumers that do not need to survive a restart.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		checkpoints: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryCheckpointStore) Load(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.checkpoints[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return append([]byte(nil), b...), nil
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, key string, checkpoint []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[key] = append([]byte(nil), checkpoint...)
+
+	return nil
+}
+
+// FileCheckpointStore keeps every checkpoint in its own file in a directory.
+// Files are replaced atomically, so a crash never leaves a torn checkpoint.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// Store checkpoints in dir, creating it if needed.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+func (s *FileCheckpointStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+func (s *FileCheckpointStore) Load(ctx context.Context, key string) ([]byte, error) {
+	b, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	return b, nil
+}
+
+func (s *FileCheckpointStore) Save(ctx context.Context, key string, checkpoint []byte) error {
+	tmp, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(checkpoint); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return err
+	}
+
+	// persist the rename itself; not every platform can sync a directory
+	if dir, err := os.Open(s.dir); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCheckpointStore(t *testing.T, store CheckpointStore) {
+	ctx := context.Background()
+
+	b, err := store.Load(ctx, "missing")
+	if err != nil || b != nil {
+		t.Fatalf("expected no checkpoint, got %q, %v", b, err)
+	}
+
+	if err := store.Save(ctx, "notifications/C2584563200", []byte("first")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := store.Save(ctx, "notifications/C2584563200", []byte("second")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err = store.Load(ctx, "notifications/C2584563200")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(b) != "second" {
+		t.Errorf("expected checkpoint to be %s, got %s", "second", b)
+	}
+}
+
+func TestMemoryCheckpointStore(t *testing.T) {
+	testCheckpointStore(t, NewMemoryCheckpointStore())
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testCheckpointStore(t, store)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected a single checkpoint file, got %d", len(entries))
+	}
+
+	// survives a restart
+	reopened, _ := NewFileCheckpointStore(dir)
+	if b, _ := reopened.Load(context.Background(), "notifications/C2584563200"); string(b) != "second" {
+		t.Errorf("expected checkpoint to be %s, got %s", "second", b)
+	}
+}
+
+func TestFileCheckpointStoreEmptyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// as left behind by a crash while writing
+	if err := os.WriteFile(store.path("notifications"), nil, 0o644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := store.Load(context.Background(), "notifications")
+	if err != nil || b != nil {
+		t.Errorf("expected no checkpoint, got %q, %v", b, err)
+	}
+
+	var mark NotificationMark
+	found, err := LoadCheckpoint(context.Background(), store, "notifications", &mark)
+	if err != nil || found {
+		t.Errorf("expected to start fresh, got %t, %v", found, err)
+	}
+}
+
+func TestLoadCheckpointEmpty(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	store.Save(context.Background(), "notifications", []byte{})
+
+	var mark NotificationMark
+	found, err := LoadCheckpoint(context.Background(), store, "notifications", &mark)
+	if err != nil || found {
+		t.Errorf("expected to start fresh, got %t, %v", found, err)
+	}
+}
+
+func TestNotificationConsumerResumesFromCheckpoint(t *testing.T) {
+	feed := notificationFeed{}
+	feed.add(
+		Notification{Notificationid: "1", ReceivedDateTime: "2024-01-01T10:00:00Z"},
+		Notification{Notificationid: "2", ReceivedDateTime: "2024-01-01T10:00:01Z"},
+	)
+
+	server := newTestServer(&feed)
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+	store := NewMemoryCheckpointStore()
+
+	var handled []string
+	handler := func(ctx context.Context, n *Notification) error {
+		handled = append(handled, n.Notificationid)
+		return nil
+	}
+
+	first := NewNotificationConsumer(api, NotificationsQuery{}, handler)
+	first.UseCheckpointStore(store, "notifications")
+
+	if _, err := first.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	feed.add(Notification{Notificationid: "3", ReceivedDateTime: "2024-01-01T10:00:02Z"})
+
+	// a new consumer, as after a restart
+	second := NewNotificationConsumer(api, NotificationsQuery{}, handler)
+	second.UseCheckpointStore(store, "notifications")
+
+	if _, err := second.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Join(handled, ",") != "1,2,3" {
+		t.Errorf("expected notifications 1,2,3 to be handled once, got %s", strings.Join(handled, ","))
+	}
+}
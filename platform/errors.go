@@ -0,0 +1,126 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/programmer-my/einvoice-go/common"
+)
+
+// APIError is returned whenever the platform responds with an unexpected
+// HTTP status. Use errors.As to inspect it:
+//
+//	var apiErr *platform.APIError
+//	if errors.As(err, &apiErr) {
+//		fmt.Println(apiErr.Response.Error.ErrorCode)
+//	}
+type APIError struct {
+	StatusCode    int
+	Response      common.StandardErrResponse // zero value if the body is not a standard error response
+	CorrelationID string                     // empty if the platform did not send one
+	Body          []byte                     // raw response body
+}
+
+// Reference: https://sdk.myinvois.hasil.gov.my/standard-error-response/
+//
+// The identity endpoints follow OAuth 2.0 instead, where "error" is a string.
+type oauthErrResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := APIError{
+		StatusCode:    resp.StatusCode,
+		CorrelationID: correlationID(resp),
+		Body:          body,
+	}
+
+	if err := json.Unmarshal(body, &apiErr.Response); err != nil {
+		var oauthErr oauthErrResponse
+		if err := json.Unmarshal(body, &oauthErr); err == nil {
+			apiErr.Response.Error.ErrorCode = oauthErr.Error
+			apiErr.Response.Error.ErrorMessage = oauthErr.ErrorDescription
+		}
+	}
+
+	return &apiErr
+}
+
+func correlationID(resp *http.Response) string {
+	if id := resp.Header.Get("correlationId"); id != "" {
+		return id
+	}
+
+	return resp.Header.Get("X-Correlation-Id")
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("platform responded with HTTP status code %d", e.StatusCode)
+
+	if e.Response.Error.ErrorCode != "" {
+		msg += ": " + e.Response.Error.ErrorCode
+	}
+
+	if e.Response.Error.ErrorMessage != "" {
+		msg += " " + e.Response.Error.ErrorMessage
+	}
+
+	for _, inner := range e.Response.Error.InnerErrors {
+		msg += fmt.Sprintf("; %s %s", inner.ErrorCode, inner.ErrorMessage)
+		if inner.PropertyPath != "" {
+			msg += " (" + inner.PropertyPath + ")"
+		}
+	}
+
+	if e.CorrelationID != "" {
+		msg += " [correlation ID " + e.CorrelationID + "]"
+	}
+
+	return msg
+}
+
+// AsAPIError returns the APIError in the chain of err, if any.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+
+	return apiErr, ok
+}
+
+func hasStatus(err error, statuses ...int) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+
+	for _, status := range statuses {
+		if apiErr.StatusCode == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The requested resource does not exist, or is not visible to the caller.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// The platform throttled the request. See Retry-After.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// The request, or a document in it, failed validation.
+func IsValidationError(err error) bool {
+	return hasStatus(err, http.StatusBadRequest, http.StatusUnprocessableEntity)
+}
+
+// The credentials or the access token were refused.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+}
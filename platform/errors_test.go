@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestResponse(status int, body string, header http.Header) (*http.Response, []byte) {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, []byte(body)
+}
+
+func TestNewAPIErrorStandardResponse(t *testing.T) {
+	header := make(http.Header)
+	header.Set("correlationId", "c0ffee")
+
+	resp, body := newTestResponse(http.StatusUnprocessableEntity, `{
+		"status": "Invalid",
+		"error": {
+			"errorCode": "Error03",
+			"error": "Duplicated Submission Validator",
+			"innerError": [
+				{
+					"propertyPath": "documents[0]",
+					"errorCode": "DS302",
+					"error": "Duplicate submission"
+				}
+			]
+		}
+	}`, header)
+
+	apiErr := newAPIError(resp, body)
+
+	if apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected StatusCode to be %d, got %d", http.StatusUnprocessableEntity, apiErr.StatusCode)
+	}
+
+	if apiErr.CorrelationID != "c0ffee" {
+		t.Errorf("expected CorrelationID to be %s, got %s", "c0ffee", apiErr.CorrelationID)
+	}
+
+	if apiErr.Response.Error.ErrorCode != "Error03" {
+		t.Errorf("expected ErrorCode to be %s, got %s", "Error03", apiErr.Response.Error.ErrorCode)
+	}
+
+	if len(apiErr.Response.Error.InnerErrors) != 1 || apiErr.Response.Error.InnerErrors[0].ErrorCode != "DS302" {
+		t.Errorf("expected inner error DS302, got %+v", apiErr.Response.Error.InnerErrors)
+	}
+
+	expectedMsg := "platform responded with HTTP status code 422: Error03 Duplicated Submission Validator; DS302 Duplicate submission (documents[0]) [correlation ID c0ffee]"
+	if apiErr.Error() != expectedMsg {
+		t.Errorf("expected message to be %q, got %q", expectedMsg, apiErr.Error())
+	}
+}
+
+func TestNewAPIErrorOAuthResponse(t *testing.T) {
+	resp, body := newTestResponse(http.StatusBadRequest, `{"error":"invalid_client","error_description":"Client blocked"}`, nil)
+
+	apiErr := newAPIError(resp, body)
+
+	if apiErr.Response.Error.ErrorCode != "invalid_client" {
+		t.Errorf("expected ErrorCode to be %s, got %s", "invalid_client", apiErr.Response.Error.ErrorCode)
+	}
+
+	if apiErr.Response.Error.ErrorMessage != "Client blocked" {
+		t.Errorf("expected ErrorMessage to be %s, got %s", "Client blocked", apiErr.Response.Error.ErrorMessage)
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	cases := []struct {
+		status       int
+		notFound     bool
+		rateLimited  bool
+		validation   bool
+		unauthorized bool
+	}{
+		{status: http.StatusNotFound, notFound: true},
+		{status: http.StatusTooManyRequests, rateLimited: true},
+		{status: http.StatusBadRequest, validation: true},
+		{status: http.StatusUnprocessableEntity, validation: true},
+		{status: http.StatusUnauthorized, unauthorized: true},
+		{status: http.StatusInternalServerError},
+	}
+
+	for _, test := range cases {
+		resp, body := newTestResponse(test.status, "", nil)
+		err := fmt.Errorf("wrapped: %w", newAPIError(resp, body))
+
+		if IsNotFound(err) != test.notFound {
+			t.Errorf("%d: expected IsNotFound to be %t", test.status, test.notFound)
+		}
+
+		if IsRateLimited(err) != test.rateLimited {
+			t.Errorf("%d: expected IsRateLimited to be %t", test.status, test.rateLimited)
+		}
+
+		if IsValidationError(err) != test.validation {
+			t.Errorf("%d: expected IsValidationError to be %t", test.status, test.validation)
+		}
+
+		if IsUnauthorized(err) != test.unauthorized {
+			t.Errorf("%d: expected IsUnauthorized to be %t", test.status, test.unauthorized)
+		}
+
+		if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != test.status {
+			t.Errorf("%d: expected AsAPIError to find the error", test.status)
+		}
+	}
+
+	if IsNotFound(fmt.Errorf("not an API error")) {
+		t.Errorf("expected IsNotFound to be false for other errors")
+	}
+}
+
+func TestGetDocumentTypeByIdNotFound(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("correlationId", "c0ffee")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	_, err := api.GetDocumentTypeById(context.Background(), "99")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if apiErr, _ := AsAPIError(err); apiErr.CorrelationID != "c0ffee" {
+		t.Errorf("expected CorrelationID to be %s, got %s", "c0ffee", apiErr.CorrelationID)
+	}
+}
@@ -0,0 +1,216 @@
+package platform
+
+import (
+	"context"
+)
+
+// pageIterator walks through the items of a paginated endpoint, fetching
+// the next page whenever the current one is used up.
+type pageIterator[T any] struct {
+	// fetch returns the items of the given page and the total number of pages
+	fetch func(ctx context.Context, pageNo int64) ([]T, int64, error)
+
+	pageNo     int64 // last page fetched
+	totalPages int64
+	items      []T
+	index      int
+	current    *T
+	err        error
+	done       bool
+}
+
+func (it *pageIterator[T]) next(ctx context.Context) bool {
+	for it.index >= len(it.items) {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		if it.items != nil && it.pageNo >= it.totalPages {
+			it.done = true
+			return false
+		}
+
+		items, totalPages, err := it.fetch(ctx, it.pageNo+1)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.pageNo++
+		it.totalPages = totalPages
+		it.items = items
+		it.index = 0
+
+		if len(items) == 0 {
+			it.done = true
+			return false
+		}
+	}
+
+	it.current = &it.items[it.index]
+	it.index++
+
+	return true
+}
+
+// pageSize returns the requested page size, capped at the maximum allowed by
+// the endpoint. Iterators default to the maximum to make as few requests as
+// possible.
+func pageSize(requested int64, max int64) int64 {
+	if requested <= 0 || requested > max {
+		return max
+	}
+
+	return requested
+}
+
+// SearchDocumentsIterator goes through every document matching a search,
+// one page at a time:
+//
+//	it := platform.NewSearchDocumentsIterator(api, query)
+//	for it.Next(ctx) {
+//		doc := it.Document()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SearchDocumentsIterator struct {
+	pages pageIterator[SearchDocumentsResult]
+}
+
+// Iterate over the results of query, starting from query.PageNo if given.
+func NewSearchDocumentsIterator(api *Api, query SearchDocumentsQuery) *SearchDocumentsIterator {
+	query.PageSize = pageSize(query.PageSize, SEARCH_DOCUMENTS_MAX_PAGE_SIZE)
+
+	firstPage := query.PageNo
+	if firstPage < 1 {
+		firstPage = 1
+	}
+
+	it := SearchDocumentsIterator{}
+	it.pages.pageNo = firstPage - 1
+	it.pages.fetch = func(ctx context.Context, pageNo int64) ([]SearchDocumentsResult, int64, error) {
+		query.PageNo = pageNo
+
+		resp, err := api.SearchDocuments(ctx, &query)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return resp.Result, resp.Metadata.TotalPages, nil
+	}
+
+	return &it
+}
+
+// Next advances to the next document, fetching the next page if needed.
+// It returns false when there are no more documents or an error occurred.
+func (it *SearchDocumentsIterator) Next(ctx context.Context) bool {
+	return it.pages.next(ctx)
+}
+
+// The current document. Only valid after Next returned true.
+func (it *SearchDocumentsIterator) Document() *SearchDocumentsResult {
+	return it.pages.current
+}
+
+// The error that stopped the iteration, if any.
+func (it *SearchDocumentsIterator) Err() error {
+	return it.pages.err
+}
+
+// RecentDocumentsIterator goes through every recent document matching a
+// query, one page at a time. See SearchDocumentsIterator for usage.
+type RecentDocumentsIterator struct {
+	pages pageIterator[RecentDocument]
+}
+
+// Iterate over the recent documents matching query, starting from
+// query.PageNo if given.
+func NewRecentDocumentsIterator(api *Api, query GetRecentDocumentsQuery) *RecentDocumentsIterator {
+	query.PageSize = pageSize(query.PageSize, GET_RECENT_DOCUMENTS_MAX_PAGE_SIZE)
+
+	firstPage := query.PageNo
+	if firstPage < 1 {
+		firstPage = 1
+	}
+
+	it := RecentDocumentsIterator{}
+	it.pages.pageNo = firstPage - 1
+	it.pages.fetch = func(ctx context.Context, pageNo int64) ([]RecentDocument, int64, error) {
+		query.PageNo = pageNo
+
+		resp, err := api.GetRecentDocuments(ctx, &query)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return resp.Result, resp.Metadata.TotalPages, nil
+	}
+
+	return &it
+}
+
+// Next advances to the next document, fetching the next page if needed.
+// It returns false when there are no more documents or an error occurred.
+func (it *RecentDocumentsIterator) Next(ctx context.Context) bool {
+	return it.pages.next(ctx)
+}
+
+// The current document. Only valid after Next returned true.
+func (it *RecentDocumentsIterator) Document() *RecentDocument {
+	return it.pages.current
+}
+
+// The error that stopped the iteration, if any.
+func (it *RecentDocumentsIterator) Err() error {
+	return it.pages.err
+}
+
+// NotificationsIterator goes through every notification matching a query,
+// one page at a time. See SearchDocumentsIterator for usage.
+type NotificationsIterator struct {
+	pages pageIterator[Notification]
+}
+
+// Iterate over the notifications matching query, starting from
+// query.PageNo if given.
+func NewNotificationsIterator(api *Api, query NotificationsQuery) *NotificationsIterator {
+	query.PageSize = pageSize(query.PageSize, GET_NOTIFICATIONS_MAX_PAGE_SIZE)
+
+	firstPage := query.PageNo
+	if firstPage < 1 {
+		firstPage = 1
+	}
+
+	it := NotificationsIterator{}
+	it.pages.pageNo = firstPage - 1
+	it.pages.fetch = func(ctx context.Context, pageNo int64) ([]Notification, int64, error) {
+		query.PageNo = pageNo
+
+		resp, err := api.GetNotifications(ctx, &query)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return resp.Result, int64(resp.Metadata.TotalPages), nil
+	}
+
+	return &it
+}
+
+// Next advances to the next notification, fetching the next page if needed.
+// It returns false when there are no more notifications or an error occurred.
+func (it *NotificationsIterator) Next(ctx context.Context) bool {
+	return it.pages.next(ctx)
+}
+
+// The current notification. Only valid after Next returned true.
+func (it *NotificationsIterator) Notification() *Notification {
+	return it.pages.current
+}
+
+// The error that stopped the iteration, if any.
+func (it *NotificationsIterator) Err() error {
+	return it.pages.err
+}
@@ -0,0 +1,80 @@
+package platform
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/common"
+)
+
+// Outcomes of a document passed to Metrics.ObserveDocuments
+const (
+	DOCUMENT_ACCEPTED = "accepted" // accepted for processing by SubmitDocument
+	DOCUMENT_REJECTED = "rejected" // rejected by SubmitDocument
+	DOCUMENT_VALID    = "valid"    // found valid by WaitForSubmission
+	DOCUMENT_INVALID  = "invalid"  // found invalid by WaitForSubmission
+)
+
+// Metrics receives measurements of the calls made to the platform, e.g. to
+// export them to Prometheus. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Called after every HTTP exchange with the platform, including retries.
+	// statusCode is 0 if no response was received. errorCode is the code of
+	// the standard error response, if any.
+	ObserveRequest(endpoint string, statusCode int, errorCode string, latency time.Duration)
+
+	// Called with how many documents had the given outcome, one of the
+	// DOCUMENT_ constants.
+	ObserveDocuments(outcome string, count int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(endpoint string, statusCode int, errorCode string, latency time.Duration) {
+}
+
+func (nopMetrics) ObserveDocuments(outcome string, count int) {}
+
+// Report requests, retries and failures to logger, e.g. a *slog.Logger.
+// Nothing is logged by default.
+func WithLogger(logger common.Logger) ApiOption {
+	return func(a *Api) {
+		a.logger = logger
+	}
+}
+
+// Report request counts, latencies, error codes and document outcomes to
+// metrics. Nothing is reported by default.
+func WithMetrics(metrics Metrics) ApiOption {
+	return func(a *Api) {
+		a.metrics = metrics
+	}
+}
+
+// observe a single HTTP exchange with the platform
+func (a *Api) observe(endpoint string, req *http.Request, attempt int, sentAt time.Time, resp *http.Response, respBytes []byte, err error) {
+	latency := time.Since(sentAt)
+
+	if a.auditHook != nil {
+		a.audit(endpoint, req, attempt, sentAt, latency, resp, respBytes, err)
+	}
+
+	if err != nil {
+		a.metrics.ObserveRequest(endpoint, 0, "", latency)
+		a.logger.Warn("platform request failed", "method", req.Method, "endpoint", endpoint, "attempt", attempt, "latency", latency, "err", err)
+		return
+	}
+
+	errorCode := ""
+	if resp.StatusCode >= 400 {
+		apiErr := newAPIError(resp, respBytes)
+		errorCode = apiErr.Response.Error.ErrorCode
+
+		a.logger.Warn("platform responded with an error", "method", req.Method, "endpoint", endpoint, "attempt", attempt, "latency", latency,
+			"status", resp.StatusCode, "errorCode", errorCode, "correlationId", apiErr.CorrelationID)
+	} else {
+		a.logger.Debug("platform request", "method", req.Method, "endpoint", endpoint, "attempt", attempt, "latency", latency, "status", resp.StatusCode)
+	}
+
+	a.metrics.ObserveRequest(endpoint, resp.StatusCode, errorCode, latency)
+}
@@ -0,0 +1,117 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) log(level string, msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.log("DEBUG", msg, args...) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.log("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.log("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.log("ERROR", msg, args...) }
+
+type recordingMetrics struct {
+	mu        sync.Mutex
+	requests  []string
+	documents map[string]int
+}
+
+func (m *recordingMetrics) ObserveRequest(endpoint string, statusCode int, errorCode string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, fmt.Sprintf("%s %d %s", endpoint, statusCode, errorCode))
+}
+
+func (m *recordingMetrics) ObserveDocuments(outcome string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.documents == nil {
+		m.documents = make(map[string]int)
+	}
+
+	m.documents[outcome] += count
+}
+
+func TestLoggerAndMetrics(t *testing.T) {
+	calls := 0
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"errorCode":"TooManyRequests"}}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"submissionUid":"SUB1","acceptedDocuments":[{"uuid":"DOC1","invoiceCodeNumber":"INV-1"}],"rejectedDocuments":[{"invoiceCodeNumber":"INV-2","error":{"errorCode":"DuplicateSubmission"}}]}`))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	metrics := &recordingMetrics{}
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}), WithLogger(logger), WithMetrics(metrics))
+
+	_, err := api.SubmitDocument(context.Background(), []Document{
+		NewDocument(FORMAT_JSON, "INV-1", []byte(`{}`)),
+		NewDocument(FORMAT_JSON, "INV-2", []byte(`{}`)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedRequests := []string{
+		TAXPAYER_LOGIN_ENDPOINT + " 200 ",
+		SUBMIT_DOCUMENT_ENDPOINT + " 429 TooManyRequests",
+		SUBMIT_DOCUMENT_ENDPOINT + " 202 ",
+	}
+
+	if strings.Join(metrics.requests, "\n") != strings.Join(expectedRequests, "\n") {
+		t.Errorf("expected requests %q, got %q", expectedRequests, metrics.requests)
+	}
+
+	if metrics.documents[DOCUMENT_ACCEPTED] != 1 || metrics.documents[DOCUMENT_REJECTED] != 1 {
+		t.Errorf("expected 1 accepted and 1 rejected document, got %v", metrics.documents)
+	}
+
+	expectedLogs := []string{
+		"WARN platform responded with an error",
+		"INFO retrying platform request",
+		"WARN document rejected",
+	}
+
+	for _, expected := range expectedLogs {
+		found := false
+		for _, entry := range logger.entries {
+			if strings.HasPrefix(entry, expected) {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("expected a %q log entry, got %q", expected, logger.entries)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layouts of the notification timestamps. Most are RFC 3339, but some come
+// without an offset, in which case they are in UTC.
+var notificationTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+func parseNotificationTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range notificationTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid notification timestamp %q", value)
+}
+
+// When the notification was received, parsed from ReceivedDateTime.
+func (n *Notification) ReceivedAt() (time.Time, error) {
+	return parseNotificationTime(n.ReceivedDateTime)
+}
+
+// When the notification was delivered, or the zero time if it was not.
+func (n *Notification) DeliveredAt() (time.Time, error) {
+	if n.DeliveredDateTime == "" {
+		return time.Time{}, nil
+	}
+
+	return parseNotificationTime(n.DeliveredDateTime)
+}
+
+// Handles a notification delivered by a NotificationConsumer. Returning an
+// error stops the poll; the notification is delivered again on the next one.
+type NotificationHandler func(ctx context.Context, n *Notification) error
+
+// NotificationMark is the high-water mark of a NotificationConsumer: every
+// notification received up to ReceivedDateTime has been handled.
+type NotificationMark struct {
+	ReceivedDateTime time.Time `json:"receivedDateTime"`
+
+	// Notifications received exactly at ReceivedDateTime that were handled,
+	// since more may still arrive with the same timestamp
+	NotificationIds []string `json:"notificationIds"`
+}
+
+func (m *NotificationMark) handled(id string, receivedAt time.Time) bool {
+	if receivedAt.Before(m.ReceivedDateTime) {
+		return true
+	}
+
+	if receivedAt.After(m.ReceivedDateTime) {
+		return false
+	}
+
+	for _, handledId := range m.NotificationIds {
+		if handledId == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *NotificationMark) advance(id string, receivedAt time.Time) {
+	if receivedAt.After(m.ReceivedDateTime) {
+		m.ReceivedDateTime = receivedAt
+		m.NotificationIds = nil
+	}
+
+	m.NotificationIds = append(m.NotificationIds, id)
+}
+
+// NotificationConsumer polls the notifications of the taxpayer and delivers
+// every new one exactly once to a handler, oldest first.
+//
+// It is not safe for concurrent use.
+type NotificationConsumer struct {
+	api     *Api
+	query   NotificationsQuery
+	handler NotificationHandler
+	mark    NotificationMark
+
+	checkpoints   CheckpointStore
+	checkpointKey string
+	resumed       bool
+}
+
+// Consume the notifications matching query. query.DateFrom is only used
+// until the first notification has been handled; paging is ignored.
+func NewNotificationConsumer(api *Api, query NotificationsQuery, handler NotificationHandler) *NotificationConsumer {
+	query.PageNo = 0
+	query.PageSize = 0
+
+	return &NotificationConsumer{
+		api:     api,
+		query:   query,
+		handler: handler,
+	}
+}
+
+// The current high-water mark.
+func (c *NotificationConsumer) Mark() NotificationMark {
+	mark := c.mark
+	mark.NotificationIds = append([]string(nil), c.mark.NotificationIds...)
+
+	return mark
+}
+
+// Resume from a mark returned by Mark.
+func (c *NotificationConsumer) SetMark(mark NotificationMark) {
+	c.mark = mark
+	c.mark.NotificationIds = append([]string(nil), mark.NotificationIds...)
+}
+
+// Persist the mark in store under key after every handled notification,
+// and resume from the saved mark on the first poll.
+//
+// A notification is handled again after a restart only if the process died
+// between handling it and saving the mark.
+func (c *NotificationConsumer) UseCheckpointStore(store CheckpointStore, key string) {
+	c.checkpoints = store
+	c.checkpointKey = key
+	c.resumed = false
+}
+
+// Poll fetches the notifications received since the mark and hands the new
+// ones to the handler. It returns how many were handled.
+func (c *NotificationConsumer) Poll(ctx context.Context) (int, error) {
+	if c.checkpoints != nil && !c.resumed {
+		var mark NotificationMark
+		found, err := LoadCheckpoint(ctx, c.checkpoints, c.checkpointKey, &mark)
+		if err != nil {
+			return 0, err
+		}
+
+		if found {
+			c.mark = mark
+		}
+
+		c.resumed = true
+	}
+
+	query := c.query
+	if !c.mark.ReceivedDateTime.IsZero() {
+		dateFrom := c.mark.ReceivedDateTime.UTC().Format(time.RFC3339)
+		query.DateFrom = &dateFrom
+	}
+
+	type pending struct {
+		notification Notification
+		receivedAt   time.Time
+	}
+
+	var fresh []pending
+
+	it := NewNotificationsIterator(c.api, query)
+	for it.Next(ctx) {
+		n := it.Notification()
+
+		// without a timestamp it cannot be placed relative to the mark
+		receivedAt, err := n.ReceivedAt()
+		if err != nil {
+			c.api.logger.Warn("skipping notification", "notificationId", n.Notificationid, "error", err)
+			continue
+		}
+
+		if !c.mark.handled(n.Notificationid, receivedAt) {
+			fresh = append(fresh, pending{notification: *n, receivedAt: receivedAt})
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].receivedAt.Before(fresh[j].receivedAt)
+	})
+
+	for i := range fresh {
+		if err := c.handler(ctx, &fresh[i].notification); err != nil {
+			return i, err
+		}
+
+		c.mark.advance(fresh[i].notification.Notificationid, fresh[i].receivedAt)
+
+		if c.checkpoints != nil {
+			if err := SaveCheckpoint(ctx, c.checkpoints, c.checkpointKey, c.mark); err != nil {
+				return i + 1, fmt.Errorf("failed to save notification checkpoint: %w", err)
+			}
+		}
+	}
+
+	return len(fresh), nil
+}
+
+// Run polls every interval until ctx is done or a poll fails.
+func (c *NotificationConsumer) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if _, err := c.Poll(ctx); err != nil {
+			return err
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
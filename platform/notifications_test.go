@@ -0,0 +1,235 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/common"
+)
+
+func TestBuildGetNotificationsRequest(t *testing.T) {
+	dateFrom := "2024-01-01T00:00:00Z"
+	notifType := NOTIFICATION_DOCUMENT_REJECTION_INITIATED
+
+	req, err := buildGetNotificationsRequest(context.Background(), common.SANDBOX_API_BASE_URL, &NotificationsQuery{
+		DateFrom: &dateFrom,
+		Type:     &notifType,
+		PageNo:   2,
+		PageSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error when building request: %s", err)
+	}
+
+	expectedQuery := "dateFrom=2024-01-01T00%3A00%3A00Z&pageNo=2&pageSize=50&type=15"
+	if req.URL.RawQuery != expectedQuery {
+		t.Errorf("expected query params to be %q, got %q", expectedQuery, req.URL.RawQuery)
+	}
+}
+
+func TestUnmarshalNotificationMetadata(t *testing.T) {
+	for _, body := range []string{
+		`{"totalPages":2,"totalCount":150}`,
+		`{"totalPages":"2","totalCount":"150"}`,
+	} {
+		var meta NotificationMetadata
+		if err := json.Unmarshal([]byte(body), &meta); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if meta.TotalPages != 2 || meta.TotalCount != 150 {
+			t.Errorf("%s: unexpected metadata %+v", body, meta)
+		}
+	}
+}
+
+// notificationFeed serves the given notifications, ignoring every filter
+// except dateFrom, which is recorded
+type notificationFeed struct {
+	mu            sync.Mutex
+	notifications []Notification
+	dateFroms     []string
+}
+
+func (f *notificationFeed) add(n ...Notification) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notifications = append(f.notifications, n...)
+}
+
+func (f *notificationFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dateFroms = append(f.dateFroms, r.URL.Query().Get("dateFrom"))
+
+	b, _ := json.Marshal(GetNotificationsResponse{
+		Result:   f.notifications,
+		Metadata: NotificationMetadata{TotalPages: 1, TotalCount: len(f.notifications)},
+	})
+	w.Write(b)
+}
+
+func TestNotificationConsumerDeliversOnce(t *testing.T) {
+	feed := notificationFeed{}
+	feed.add(
+		Notification{Notificationid: "2", ReceivedDateTime: "2024-01-01T10:00:05Z", TypeId: NOTIFICATION_DOCUMENT_REJECTION_INITIATED},
+		Notification{Notificationid: "1", ReceivedDateTime: "2024-01-01T10:00:00Z", TypeId: NOTIFICATION_DOCUMENT_RECEIVED},
+	)
+
+	server := newTestServer(&feed)
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	var handled []string
+	consumer := NewNotificationConsumer(api, NotificationsQuery{}, func(ctx context.Context, n *Notification) error {
+		handled = append(handled, n.Notificationid)
+		return nil
+	})
+
+	if _, err := consumer.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// same timestamp as the mark, but not handled yet
+	feed.add(Notification{Notificationid: "3", ReceivedDateTime: "2024-01-01T10:00:05Z"})
+
+	n, err := consumer.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 new notification, got %d", n)
+	}
+
+	if strings.Join(handled, ",") != "1,2,3" {
+		t.Errorf("expected notifications 1,2,3 to be handled once, got %s", strings.Join(handled, ","))
+	}
+
+	if feed.dateFroms[0] != "" || feed.dateFroms[1] != "2024-01-01T10:00:05Z" {
+		t.Errorf("expected the second poll to start from the mark, got %v", feed.dateFroms)
+	}
+
+	mark := consumer.Mark()
+	if mark.ReceivedDateTime.Format("15:04:05") != "10:00:05" || strings.Join(mark.NotificationIds, ",") != "2,3" {
+		t.Errorf("unexpected mark %+v", mark)
+	}
+}
+
+func TestNotificationConsumerRedeliversAfterHandlerError(t *testing.T) {
+	feed := notificationFeed{}
+	feed.add(
+		Notification{Notificationid: "1", ReceivedDateTime: "2024-01-01T10:00:00Z"},
+		Notification{Notificationid: "2", ReceivedDateTime: "2024-01-01T10:00:01Z"},
+	)
+
+	server := newTestServer(&feed)
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	errHandler := errors.New("handler failed")
+	fail := true
+
+	var handled []string
+	consumer := NewNotificationConsumer(api, NotificationsQuery{}, func(ctx context.Context, n *Notification) error {
+		if n.Notificationid == "2" && fail {
+			fail = false
+			return errHandler
+		}
+
+		handled = append(handled, n.Notificationid)
+		return nil
+	})
+
+	if _, err := consumer.Poll(context.Background()); !errors.Is(err, errHandler) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+
+	if _, err := consumer.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Join(handled, ",") != "1,2" {
+		t.Errorf("expected notifications 1,2 to be handled once, got %s", strings.Join(handled, ","))
+	}
+}
+
+func TestNotificationReceivedAt(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-01-01T10:00:00Z", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-01T18:00:00+08:00", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-01T10:00:00.1234567Z", time.Date(2024, 1, 1, 10, 0, 0, 123456700, time.UTC)},
+		{"2024-01-01T10:00:00.1234567", time.Date(2024, 1, 1, 10, 0, 0, 123456700, time.UTC)},
+		{"2024-01-01T10:00:00", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-01 10:00:00", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		n := Notification{ReceivedDateTime: test.value}
+
+		actual, err := n.ReceivedAt()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.value, err)
+			continue
+		}
+
+		if !actual.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.value, test.expected, actual)
+		}
+	}
+
+	n := Notification{ReceivedDateTime: "yesterday"}
+	if _, err := n.ReceivedAt(); err == nil {
+		t.Errorf("expected error for invalid timestamp")
+	}
+
+	if delivered, err := n.DeliveredAt(); err != nil || !delivered.IsZero() {
+		t.Errorf("expected zero time for an undelivered notification, got %s, %v", delivered, err)
+	}
+}
+
+func TestNotificationConsumerToleratesTimestamps(t *testing.T) {
+	feed := notificationFeed{}
+	feed.add(
+		Notification{Notificationid: "1", ReceivedDateTime: "2024-01-01T10:00:00.1234567"},
+		Notification{Notificationid: "2", ReceivedDateTime: "not a timestamp"},
+		Notification{Notificationid: "3", ReceivedDateTime: "2024-01-01T18:00:01+08:00"},
+	)
+
+	server := newTestServer(&feed)
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	var handled []string
+	consumer := NewNotificationConsumer(api, NotificationsQuery{}, func(ctx context.Context, n *Notification) error {
+		handled = append(handled, n.Notificationid)
+		return nil
+	})
+
+	n, err := consumer.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n != 2 || strings.Join(handled, ",") != "1,3" {
+		t.Errorf("expected notifications 1,3 to be handled, got %s", strings.Join(handled, ","))
+	}
+
+	if mark := consumer.Mark(); !mark.ReceivedDateTime.Equal(time.Date(2024, 1, 1, 10, 0, 1, 0, time.UTC)) {
+		t.Errorf("unexpected mark %+v", mark)
+	}
+}
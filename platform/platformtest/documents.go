@@ -0,0 +1,971 @@
+package platformtest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/programmer-my/einvoice-go/common"
+	"github.com/programmer-my/einvoice-go/platform"
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+// Status of a document on the platform
+const (
+	STATUS_SUBMITTED = "Submitted"
+	STATUS_VALID     = "Valid"
+	STATUS_INVALID   = "Invalid"
+	STATUS_CANCELLED = "Cancelled"
+)
+
+// A document must be submitted within this long of being issued.
+const ISSUE_DATE_WINDOW = 72 * time.Hour
+
+// Supported document type versions
+var documentVersions = map[string]bool{"1.0": true, "1.1": true}
+
+// Document type names by invoice type code
+var documentTypeNames = map[string]string{
+	"01": "Invoice",
+	"02": "Credit Note",
+	"03": "Debit Note",
+	"04": "Refund Note",
+	"11": "Self-billed Invoice",
+	"12": "Self-billed Credit Note",
+	"13": "Self-billed Debit Note",
+	"14": "Self-billed Refund Note",
+}
+
+// A Check inspects a submitted document. Return an error to fail the
+// document, or nil to let it through.
+type Check func(doc *Document) *common.ErrResponse
+
+// Document is a document submitted to the fake, as returned by
+// Server.Document.
+type Document struct {
+	UUID              string
+	SubmissionUid     string
+	LongId            string // only for valid documents
+	CodeNumber        string
+	Format            platform.DocumentFormat
+	Raw               []byte
+	Invoice           *ubl.UBL_Invoice // parsed document, XML or JSON
+	Status            string           // one of the STATUS_ constants
+	StatusReason      string           // reason of the cancellation or rejection
+	ReceivedAt        time.Time
+	ValidatedAt       time.Time // zero until the submission is processed
+	CancelledAt       time.Time // zero unless cancelled
+	RejectRequestedAt time.Time // zero unless the receiver requested a rejection
+	ValidationSteps   []platform.DocumentValidationStep
+
+	// validation results, applied when the submission is processed
+	pendingSteps []platform.DocumentValidationStep
+}
+
+type submission struct {
+	uid        string
+	receivedAt time.Time
+	uuids      []string // accepted documents, in the order they were submitted
+	polls      int      // polls left before the submission is processed
+}
+
+// Keep every submission in progress for n polls of GetSubmission before
+// validating its documents. The default is 0, i.e. submissions are
+// processed right away.
+func (s *Server) SetProcessingPolls(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.processingPolls = n
+}
+
+// Reject the documents for which check fails when they are submitted, in
+// addition to the built-in checks. Rejected documents are listed in
+// rejectedDocuments and never get a UUID.
+func (s *Server) RejectWhen(check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rejectChecks = append(s.rejectChecks, check)
+}
+
+// Mark the documents for which check fails as invalid when their
+// submission is processed, in addition to the built-in checks. The check
+// runs when the document is submitted.
+func (s *Server) InvalidateWhen(check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.invalidChecks = append(s.invalidChecks, check)
+}
+
+// A copy of the document with the given UUID.
+func (s *Server) Document(uuid string) (Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[uuid]
+	if !ok {
+		return Document{}, false
+	}
+
+	return *doc, true
+}
+
+// Every document accepted so far, oldest first.
+func (s *Server) Documents() []Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs := make([]Document, len(s.documentOrder))
+	for i, uuid := range s.documentOrder {
+		docs[i] = *s.documents[uuid]
+	}
+
+	return docs
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request, _ string) {
+	if r.ContentLength > platform.MAX_SUBMISSION_SIZE {
+		writeError(w, http.StatusBadRequest, "MaximumSizeExceeded", fmt.Sprintf("submission exceeds %d bytes", platform.MAX_SUBMISSION_SIZE))
+		return
+	}
+
+	var req platform.SubmitDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "BadArgument", err.Error())
+		return
+	}
+
+	if len(req.Documents) == 0 || len(req.Documents) > platform.MAX_DOCUMENTS_PER_SUBMISSION {
+		writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("a submission must contain between 1 and %d documents", platform.MAX_DOCUMENTS_PER_SUBMISSION))
+		return
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	rejectChecks := append([]Check(nil), s.rejectChecks...)
+	invalidChecks := append([]Check(nil), s.invalidChecks...)
+	s.mu.Unlock()
+
+	type prepared struct {
+		doc *Document
+		err *common.ErrResponse
+	}
+
+	// checks run without holding the lock, since they are provided by the test
+	docs := make([]prepared, len(req.Documents))
+	for i, d := range req.Documents {
+		doc, err := prepareDocument(d, now)
+		if err == nil {
+			for _, check := range rejectChecks {
+				if err = check(doc); err != nil {
+					break
+				}
+			}
+		}
+
+		if err == nil {
+			doc.pendingSteps = validateDocument(doc, now, invalidChecks)
+		}
+
+		docs[i] = prepared{doc: doc, err: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := platform.SubmitDocumentResponse{
+		AcceptedDocuments: []platform.AcceptedDocuments{},
+		RejectedDocuments: []platform.RejectedDocuments{},
+	}
+
+	sub := &submission{
+		uid:        s.nextId("SUB", 26),
+		receivedAt: now,
+		polls:      s.processingPolls,
+	}
+
+	seen := make(map[string]bool)
+
+	for _, p := range docs {
+		err := p.err
+		if err == nil && (seen[p.doc.CodeNumber] || s.isDuplicate(p.doc.CodeNumber)) {
+			err = &common.ErrResponse{
+				ErrorCode:    "DuplicateSubmission",
+				ErrorMessage: fmt.Sprintf("document %s was already submitted", p.doc.CodeNumber),
+				PropertyName: "codeNumber",
+				Target:       p.doc.CodeNumber,
+			}
+		}
+
+		if err != nil {
+			resp.RejectedDocuments = append(resp.RejectedDocuments, platform.RejectedDocuments{
+				InvoiceCodeNumber: p.doc.CodeNumber,
+				Error:             *err,
+			})
+			continue
+		}
+
+		seen[p.doc.CodeNumber] = true
+
+		p.doc.UUID = s.nextId("DOC", 26)
+		p.doc.SubmissionUid = sub.uid
+
+		s.documents[p.doc.UUID] = p.doc
+		s.documentOrder = append(s.documentOrder, p.doc.UUID)
+		sub.uuids = append(sub.uuids, p.doc.UUID)
+
+		resp.AcceptedDocuments = append(resp.AcceptedDocuments, platform.AcceptedDocuments{
+			UUID:              p.doc.UUID,
+			InvoiceCodeNumber: p.doc.CodeNumber,
+		})
+	}
+
+	if len(sub.uuids) > 0 {
+		resp.SubmissionUID = sub.uid
+		s.submissions[sub.uid] = sub
+
+		s.notify(platform.NOTIFICATION_DOCUMENT_RECEIVED, "Documents received", fmt.Sprintf("Submission %s with %d documents was received", sub.uid, len(sub.uuids)))
+
+		if sub.polls <= 0 {
+			s.process(sub)
+		}
+	}
+
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// whether an accepted document with the same code number is still pending
+// or valid
+func (s *Server) isDuplicate(codeNumber string) bool {
+	for _, doc := range s.documents {
+		if doc.CodeNumber == codeNumber && (doc.Status == STATUS_SUBMITTED || doc.Status == STATUS_VALID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decode a submitted document and run the checks that reject it outright
+func prepareDocument(d platform.Document, now time.Time) (*Document, *common.ErrResponse) {
+	doc := &Document{
+		CodeNumber: d.CodeNumber,
+		Format:     d.Format,
+		Status:     STATUS_SUBMITTED,
+		ReceivedAt: now,
+	}
+
+	reject := func(code string, property string, format string, args ...any) (*Document, *common.ErrResponse) {
+		return doc, &common.ErrResponse{
+			ErrorCode:    code,
+			ErrorMessage: fmt.Sprintf(format, args...),
+			PropertyName: property,
+			Target:       d.CodeNumber,
+		}
+	}
+
+	if d.CodeNumber == "" {
+		return reject("BadArgument", "codeNumber", "codeNumber is required")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(d.Document)
+	if err != nil {
+		return reject("BadStructure", "document", "document is not valid base64: %s", err)
+	}
+
+	doc.Raw = raw
+
+	if len(raw) > platform.MAX_DOCUMENT_SIZE {
+		return reject("MaximumSizeExceeded", "document", "document is %d bytes, exceeding %d bytes", len(raw), platform.MAX_DOCUMENT_SIZE)
+	}
+
+	hash := sha256.Sum256(raw)
+	if !strings.EqualFold(d.DocumentSHA256, hex.EncodeToString(hash[:])) {
+		return reject("IncorrectHash", "documentHash", "documentHash does not match the document")
+	}
+
+	var inv *ubl.UBL_Invoice
+
+	switch d.Format {
+	case platform.FORMAT_XML:
+		inv, err = ubl.ParseInvoice(raw)
+	case platform.FORMAT_JSON:
+		inv, err = ubl.ParseInvoiceJSON(raw)
+	default:
+		return reject("BadArgument", "format", "unsupported format %q", d.Format)
+	}
+
+	if err != nil {
+		return reject("BadStructure", "document", "%s", err)
+	}
+
+	doc.Invoice = inv
+
+	if inv.ID != d.CodeNumber {
+		return reject("BadArgument", "codeNumber", "codeNumber %s does not match the document ID %s", d.CodeNumber, inv.ID)
+	}
+
+	return doc, nil
+}
+
+// run the validation steps of an accepted document
+func validateDocument(doc *Document, now time.Time, checks []Check) []platform.DocumentValidationStep {
+	steps := []platform.DocumentValidationStep{
+		{Name: "Step01-Structure Validator", Status: STATUS_VALID},
+		validationStep("Step02-Core Fields Validator", "CF001", validateCoreFields(doc.Invoice, now)),
+	}
+
+	var errs []common.ErrResponse
+	for _, check := range checks {
+		if err := check(doc); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	return append(steps, validationStep("Step03-Custom Validator", "CV001", errs))
+}
+
+func validationStep(name string, code string, errs []common.ErrResponse) platform.DocumentValidationStep {
+	if len(errs) == 0 {
+		return platform.DocumentValidationStep{Name: name, Status: STATUS_VALID}
+	}
+
+	return platform.DocumentValidationStep{
+		Name:   name,
+		Status: STATUS_INVALID,
+		Error: &common.ErrResponse{
+			ErrorCode:    code,
+			ErrorMessage: "Validation failed",
+			InnerErrors:  errs,
+		},
+	}
+}
+
+// the mandatory fields of an invoice, parsed from XML or JSON alike
+func validateCoreFields(inv *ubl.UBL_Invoice, now time.Time) []common.ErrResponse {
+	if inv == nil {
+		return nil
+	}
+
+	var errs []common.ErrResponse
+
+	invalid := func(property string, format string, args ...any) {
+		errs = append(errs, common.ErrResponse{
+			ErrorCode:    "InvalidValue",
+			ErrorMessage: fmt.Sprintf(format, args...),
+			PropertyName: property,
+			PropertyPath: "Invoice." + property,
+		})
+	}
+
+	issueTime := "00:00:00"
+	if inv.IssueTime != nil {
+		issueTime = strings.TrimSuffix(*inv.IssueTime, "Z")
+	}
+
+	issuedAt, err := time.Parse("2006-01-02 15:04:05", inv.IssueDate+" "+issueTime)
+	if err != nil {
+		invalid("IssueDate", "invalid issue date and time %q %q", inv.IssueDate, issueTime)
+	} else if issuedAt.After(now) || now.Sub(issuedAt) > ISSUE_DATE_WINDOW {
+		invalid("IssueDate", "the document must be submitted within %s of its issue date", ISSUE_DATE_WINDOW)
+	}
+
+	if _, ok := documentTypeNames[inv.InvoiceTypeCode.Value]; !ok {
+		invalid("InvoiceTypeCode", "unknown invoice type code %q", inv.InvoiceTypeCode.Value)
+	}
+
+	if !documentVersions[inv.InvoiceTypeCode.ListVersionID] {
+		invalid("InvoiceTypeCode.listVersionID", "unsupported document version %q", inv.InvoiceTypeCode.ListVersionID)
+	}
+
+	if inv.DocumentCurrencyCode == "" {
+		invalid("DocumentCurrencyCode", "document currency code is required")
+	}
+
+	if len(inv.InvoiceLine) == 0 {
+		invalid("InvoiceLine", "at least one invoice line is required")
+	}
+
+	return errs
+}
+
+// apply the validation results of every document in the submission
+func (s *Server) process(sub *submission) {
+	now := s.now()
+
+	for _, uuid := range sub.uuids {
+		doc := s.documents[uuid]
+		if doc.Status != STATUS_SUBMITTED {
+			continue
+		}
+
+		doc.ValidationSteps = doc.pendingSteps
+		doc.ValidatedAt = now
+		doc.Status = STATUS_VALID
+
+		for _, step := range doc.ValidationSteps {
+			if step.Status == STATUS_INVALID {
+				doc.Status = STATUS_INVALID
+			}
+		}
+
+		if doc.Status == STATUS_VALID {
+			doc.LongId = s.nextId("LONG", 40)
+			s.notify(platform.NOTIFICATION_DOCUMENT_VALIDATED, "Document validated", fmt.Sprintf("Document %s (%s) is valid", doc.CodeNumber, doc.UUID))
+		}
+	}
+}
+
+// overall status of a processed submission
+func (s *Server) overallStatus(sub *submission) string {
+	valid, invalid := 0, 0
+
+	for _, uuid := range sub.uuids {
+		switch s.documents[uuid].Status {
+		case STATUS_SUBMITTED:
+			return "In Progress"
+		case STATUS_INVALID:
+			invalid++
+		default:
+			valid++
+		}
+	}
+
+	switch {
+	case invalid == 0:
+		return "Valid"
+	case valid == 0:
+		return "Invalid"
+	default:
+		return "Partially Valid"
+	}
+}
+
+func (s *Server) handleGetSubmission(w http.ResponseWriter, r *http.Request, uid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.submissions[uid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("submission %s not found", uid))
+		return
+	}
+
+	if sub.polls > 0 {
+		sub.polls--
+
+		if sub.polls == 0 {
+			s.process(sub)
+		}
+	}
+
+	summaries := make([]platform.SubmissionDocumentSummary, len(sub.uuids))
+	for i, uuid := range sub.uuids {
+		summaries[i] = s.documents[uuid].summary()
+	}
+
+	summaries, _, ok = page(w, r, summaries, platform.GET_SUBMISSION_MAX_PAGE_SIZE)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, platform.GetSubmissionResponse{
+		SubmissionUid:    sub.uid,
+		DocumentCount:    int64(len(sub.uuids)),
+		DateTimeReceived: formatTime(sub.receivedAt),
+		OverallStatus:    s.overallStatus(sub),
+		DocumentSummary:  summaries,
+	})
+}
+
+func (s *Server) handleGetDocument(w http.ResponseWriter, r *http.Request, uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("document %s not found", uuid))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, platform.GetDocumentResponse{
+		SubmissionDocumentSummary: doc.summary(),
+		Document:                  string(doc.Raw),
+	})
+}
+
+func (s *Server) handleGetDocumentDetails(w http.ResponseWriter, r *http.Request, uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("document %s not found", uuid))
+		return
+	}
+
+	resp := platform.GetDocumentDetailsResponse{
+		SubmissionDocumentSummary: doc.summary(),
+	}
+
+	if doc.Status == STATUS_INVALID {
+		resp.ValidationResults = platform.DocumentValidationResults{
+			Status:          STATUS_INVALID,
+			ValidationSteps: doc.ValidationSteps,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleStateChange(w http.ResponseWriter, r *http.Request, uuid string) {
+	var req platform.CancelDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "BadArgument", err.Error())
+		return
+	}
+
+	if req.Reason == "" || len(req.Reason) > platform.MAX_STATE_CHANGE_REASON_LENGTH {
+		writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("reason is required and cannot exceed %d characters", platform.MAX_STATE_CHANGE_REASON_LENGTH))
+		return
+	}
+
+	status := strings.ToLower(req.DesiredStatus)
+	if status != "cancelled" && status != "rejected" {
+		writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("unsupported status %q", req.DesiredStatus))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("document %s not found", uuid))
+		return
+	}
+
+	now := s.now()
+
+	if doc.Status != STATUS_VALID {
+		writeError(w, http.StatusBadRequest, "IncorrectState", fmt.Sprintf("document %s is %s", uuid, doc.Status))
+		return
+	}
+
+	if now.Sub(doc.ValidatedAt) > platform.STATE_CHANGE_WINDOW {
+		writeError(w, http.StatusBadRequest, "OperationPeriodOver", fmt.Sprintf("document %s was validated more than %s ago", uuid, platform.STATE_CHANGE_WINDOW))
+		return
+	}
+
+	doc.StatusReason = req.Reason
+	resp := platform.CancelDocumentResponse{UUID: doc.UUID}
+
+	if status == "cancelled" {
+		resp.Status = "Cancelled"
+		doc.Status = STATUS_CANCELLED
+		doc.CancelledAt = now
+		s.notify(platform.NOTIFICATION_DOCUMENT_CANCELLED, "Document cancelled", fmt.Sprintf("Document %s (%s) was cancelled", doc.CodeNumber, doc.UUID))
+	} else {
+		resp.Status = "Rejected"
+		doc.RejectRequestedAt = now
+		s.notify(platform.NOTIFICATION_DOCUMENT_REJECTION_INITIATED, "Document rejection initiated", fmt.Sprintf("Rejection of document %s (%s) was requested", doc.CodeNumber, doc.UUID))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRecentDocuments(w http.ResponseWriter, r *http.Request, _ string) {
+	query := r.URL.Query()
+
+	filter, ok := newDocumentFilter(w, query, "direction")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	docs := s.findDocuments(filter)
+	s.mu.Unlock()
+
+	docs, meta, ok := page(w, r, docs, platform.GET_RECENT_DOCUMENTS_MAX_PAGE_SIZE)
+	if !ok {
+		return
+	}
+
+	resp := platform.GetRecentDocumentsResponse{
+		Result:   make([]platform.RecentDocument, len(docs)),
+		Metadata: meta,
+	}
+
+	for i, doc := range docs {
+		summary := doc.summary()
+		resp.Result[i] = platform.RecentDocument{
+			UUID:                  summary.UUID,
+			SubmissionUid:         summary.SubmissionUid,
+			LongId:                summary.LongId,
+			InternalId:            summary.InternalId,
+			TypeName:              summary.TypeName,
+			TypeVersionName:       summary.TypeVersionName,
+			IssuerTIN:             summary.IssuerTIN,
+			IssuerName:            summary.IssuerName,
+			ReceiverId:            summary.ReceiverId,
+			ReceiverName:          summary.ReceiverName,
+			DateTimeIssued:        summary.DateTimeIssued,
+			DateTimeReceived:      summary.DateTimeReceived,
+			DateTimeValidated:     summary.DateTimeValidated,
+			TotalSales:            summary.TotalExcludingTax,
+			TotalDiscount:         summary.TotalDiscount,
+			NetAmount:             summary.TotalNetAmount,
+			Total:                 summary.TotalPayableAmount,
+			Status:                summary.Status,
+			CancelDateTime:        summary.CancelDateTime,
+			RejectRequestDateTime: summary.RejectRequestDateTime,
+			DocumentStatusReason:  summary.DocumentStatusReason,
+			SupplierTIN:           summary.IssuerTIN,
+			SupplierName:          summary.IssuerName,
+			SubmissionChannel:     "ERP",
+			BuyerName:             summary.ReceiverName,
+			BuyerTIN:              doc.receiverTIN(),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSearchDocuments(w http.ResponseWriter, r *http.Request, _ string) {
+	query := r.URL.Query()
+
+	if query.Get("submissionDateFrom") == "" && query.Get("issueDateFrom") == "" {
+		writeError(w, http.StatusBadRequest, "BadArgument", "either submissionDateFrom or issueDateFrom is required")
+		return
+	}
+
+	filter, ok := newDocumentFilter(w, query, "invoiceDirection")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	docs := s.findDocuments(filter)
+	s.mu.Unlock()
+
+	docs, meta, ok := page(w, r, docs, platform.SEARCH_DOCUMENTS_MAX_PAGE_SIZE)
+	if !ok {
+		return
+	}
+
+	resp := platform.SearchDocumentsResponse{
+		Result:   make([]platform.SearchDocumentsResult, len(docs)),
+		Metadata: meta,
+	}
+
+	for i, doc := range docs {
+		summary := doc.summary()
+		resp.Result[i] = platform.SearchDocumentsResult{
+			UUID:                  summary.UUID,
+			SubmissionUid:         summary.SubmissionUid,
+			LongId:                summary.LongId,
+			InternalId:            summary.InternalId,
+			TypeName:              summary.TypeName,
+			TypeVersionName:       summary.TypeVersionName,
+			IssuerTIN:             summary.IssuerTIN,
+			IssuerName:            summary.IssuerName,
+			ReceiverId:            summary.ReceiverId,
+			ReceiverName:          summary.ReceiverName,
+			DateTimeIssued:        summary.DateTimeIssued,
+			DateTimeReceived:      summary.DateTimeReceived,
+			DateTimeValidated:     summary.DateTimeValidated,
+			TotalExcludingTax:     summary.TotalExcludingTax,
+			TotalDiscount:         summary.TotalDiscount,
+			TotalNetAmount:        summary.TotalNetAmount,
+			TotalPayableAmount:    summary.TotalPayableAmount,
+			Status:                summary.Status,
+			CancelDateTime:        summary.CancelDateTime,
+			RejectRequestDateTime: summary.RejectRequestDateTime,
+			DocumentStatusReason:  summary.DocumentStatusReason,
+			SupplierTIN:           summary.IssuerTIN,
+			SupplierName:          summary.IssuerName,
+			SubmissionChannel:     "ERP",
+			BuyerName:             summary.ReceiverName,
+			BuyerTIN:              doc.receiverTIN(),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type documentFilter struct {
+	uuid           string
+	status         string
+	documentType   string
+	direction      string
+	search         string
+	submissionFrom time.Time
+	submissionTo   time.Time
+	issueFrom      time.Time
+	issueTo        time.Time
+}
+
+func newDocumentFilter(w http.ResponseWriter, query map[string][]string, directionParam string) (*documentFilter, bool) {
+	get := func(key string) string {
+		if v := query[key]; len(v) > 0 {
+			return v[0]
+		}
+
+		return ""
+	}
+
+	f := documentFilter{
+		uuid:         get("uuid"),
+		status:       get("status"),
+		documentType: get("documentType"),
+		direction:    get("direction"),
+		search:       get("searchQuery"),
+	}
+
+	if directionParam != "direction" {
+		f.direction = get(directionParam)
+	}
+
+	for _, t := range []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"submissionDateFrom", &f.submissionFrom},
+		{"submissionDateTo", &f.submissionTo},
+		{"issueDateFrom", &f.issueFrom},
+		{"issueDateTo", &f.issueTo},
+	} {
+		value := get(t.param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("invalid %s %q", t.param, value))
+			return nil, false
+		}
+
+		*t.dst = parsed
+	}
+
+	return &f, true
+}
+
+// the documents matching f, newest first
+func (s *Server) findDocuments(f *documentFilter) []*Document {
+	var found []*Document
+
+	for i := len(s.documentOrder) - 1; i >= 0; i-- {
+		doc := s.documents[s.documentOrder[i]]
+		summary := doc.summary()
+
+		if f.uuid != "" && doc.UUID != f.uuid {
+			continue
+		}
+
+		if f.status != "" && !strings.EqualFold(doc.Status, f.status) {
+			continue
+		}
+
+		if f.documentType != "" && (doc.Invoice == nil || doc.Invoice.InvoiceTypeCode.Value != f.documentType) {
+			continue
+		}
+
+		// every document in the fake was sent by the taxpayer
+		if f.direction != "" && !strings.EqualFold(f.direction, "Sent") {
+			continue
+		}
+
+		if !inRange(doc.ReceivedAt, f.submissionFrom, f.submissionTo) {
+			continue
+		}
+
+		issuedAt, _ := time.Parse(time.RFC3339, summary.DateTimeIssued)
+		if !inRange(issuedAt, f.issueFrom, f.issueTo) {
+			continue
+		}
+
+		if f.search != "" && !matchesSearch(f.search, doc.UUID, doc.CodeNumber, summary.IssuerTIN, summary.IssuerName, summary.ReceiverName, doc.receiverTIN(), string(summary.TotalPayableAmount)) {
+			continue
+		}
+
+		found = append(found, doc)
+	}
+
+	return found
+}
+
+func inRange(t time.Time, from time.Time, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+
+	return to.IsZero() || !t.After(to)
+}
+
+func matchesSearch(search string, values ...string) bool {
+	for _, v := range values {
+		if v != "" && strings.Contains(strings.ToLower(v), strings.ToLower(search)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// page returns the page of items requested by the pageNo and pageSize query
+// parameters, responding with 400 if they are invalid
+func page[T any](w http.ResponseWriter, r *http.Request, items []T, maxPageSize int64) ([]T, platform.PageMetadata, bool) {
+	pageNo, pageSize := int64(1), maxPageSize
+
+	for _, p := range []struct {
+		param string
+		dst   *int64
+	}{
+		{"pageNo", &pageNo},
+		{"pageSize", &pageSize},
+	} {
+		value := r.URL.Query().Get(p.param)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("invalid %s %q", p.param, value))
+			return nil, platform.PageMetadata{}, false
+		}
+
+		*p.dst = n
+	}
+
+	if pageSize > maxPageSize {
+		writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("pageSize cannot exceed %d", maxPageSize))
+		return nil, platform.PageMetadata{}, false
+	}
+
+	total := int64(len(items))
+	meta := platform.PageMetadata{
+		TotalPages: (total + pageSize - 1) / pageSize,
+		TotalCount: total,
+	}
+
+	start := (pageNo - 1) * pageSize
+	if start >= total {
+		return []T{}, meta, true
+	}
+
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	return items[start:end], meta, true
+}
+
+func (doc *Document) summary() platform.SubmissionDocumentSummary {
+	summary := platform.SubmissionDocumentSummary{
+		UUID:                  doc.UUID,
+		SubmissionUid:         doc.SubmissionUid,
+		LongId:                doc.LongId,
+		InternalId:            doc.CodeNumber,
+		Status:                doc.Status,
+		DateTimeReceived:      formatTime(doc.ReceivedAt),
+		DateTimeValidated:     formatTime(doc.ValidatedAt),
+		CancelDateTime:        formatTime(doc.CancelledAt),
+		RejectRequestDateTime: formatTime(doc.RejectRequestedAt),
+		DocumentStatusReason:  doc.StatusReason,
+	}
+
+	inv := doc.Invoice
+	if inv == nil {
+		return summary
+	}
+
+	summary.TypeName = documentTypeNames[inv.InvoiceTypeCode.Value]
+	summary.TypeVersionName = inv.InvoiceTypeCode.ListVersionID
+
+	issueTime := "00:00:00"
+	if inv.IssueTime != nil {
+		issueTime = strings.TrimSuffix(*inv.IssueTime, "Z")
+	}
+	summary.DateTimeIssued = inv.IssueDate + "T" + issueTime + "Z"
+
+	supplier := inv.AccountingSupplierParty.Party
+	summary.IssuerTIN = partyTIN(supplier)
+	summary.IssuerName = supplier.PartyLegalEntity.RegistrationName
+
+	customer := inv.AccountingCustomerParty.Party
+	summary.ReceiverName = customer.PartyLegalEntity.RegistrationName
+	for _, id := range customer.PartyIdentification {
+		if id.ID.SchemeID != "TIN" {
+			summary.ReceiverId = id.ID.Value
+			break
+		}
+	}
+
+	totals := inv.LegalMonetaryTotal
+	summary.TotalExcludingTax = amount(totals.TaxExclusiveAmount.Value, totals.TaxExclusiveAmount.CurrencyID)
+	summary.TotalNetAmount = amount(totals.LineExtensionAmount.Value, totals.LineExtensionAmount.CurrencyID)
+	summary.TotalPayableAmount = amount(totals.PayableAmount.Value, totals.PayableAmount.CurrencyID)
+	summary.TotalDiscount = "0"
+	if totals.AllowanceTotalAmount != nil {
+		summary.TotalDiscount = amount(totals.AllowanceTotalAmount.Value, totals.AllowanceTotalAmount.CurrencyID)
+	}
+
+	return summary
+}
+
+func (doc *Document) receiverTIN() string {
+	if doc.Invoice == nil {
+		return ""
+	}
+
+	return partyTIN(doc.Invoice.AccountingCustomerParty.Party)
+}
+
+func partyTIN(party ubl.CAC_Party) string {
+	for _, id := range party.PartyIdentification {
+		if id.ID.SchemeID == "TIN" {
+			return id.ID.Value
+		}
+	}
+
+	return ""
+}
+
+// an amount in major units, e.g. 124.09
+func amount(value money.Amount, currency money.Currency) json.Number {
+	if currency.Code == "" {
+		currency = *money.GetCurrency(money.MYR)
+	}
+
+	return json.Number(fmt.Sprintf("%.*f", currency.Fraction, float64(value)/pow10(currency.Fraction)))
+}
+
+func pow10(n int) float64 {
+	p := 1.0
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,408 @@
+// Package platformtest provides an in-memory fake of the MyInvois platform,
+// served over HTTP, to test integrations end to end without network access.
+//
+//	server := platformtest.NewServer()
+//	defer server.Close()
+//
+//	api := server.NewApi()
+//	resp, err := api.SubmitDocument(ctx, docs)
+//
+// Failures such as rate limiting, expired tokens or invalid documents can
+// be scripted on the server between calls.
+package platformtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/common"
+	"github.com/programmer-my/einvoice-go/platform"
+)
+
+// Every endpoint served by the fake, in the order they are matched.
+var endpoints = []struct {
+	method   string
+	endpoint string
+	handle   func(s *Server, w http.ResponseWriter, r *http.Request, param string)
+}{
+	{http.MethodPost, platform.TAXPAYER_LOGIN_ENDPOINT, (*Server).handleLogin},
+	{http.MethodPost, platform.SUBMIT_DOCUMENT_ENDPOINT, (*Server).handleSubmit},
+	{http.MethodGet, platform.GET_SUBMISSION_ENDPOINT, (*Server).handleGetSubmission},
+	{http.MethodGet, platform.GET_RECENT_DOCUMENTS_ENDPOINT, (*Server).handleRecentDocuments},
+	{http.MethodGet, platform.SEARCH_DOCUMENTS_ENDPOINT, (*Server).handleSearchDocuments},
+	{http.MethodGet, platform.GET_DOCUMENT_ENDPOINT, (*Server).handleGetDocument},
+	{http.MethodGet, platform.GET_DOCUMENT_DETAILS_ENDPOINT, (*Server).handleGetDocumentDetails},
+	{http.MethodPut, platform.CANCEL_DOCUMENT_ENDPOINT, (*Server).handleStateChange}, // also REJECT_DOCUMENT_ENDPOINT
+	{http.MethodGet, platform.VALIDATE_TIN_ENDPOINT, (*Server).handleValidateTIN},
+	{http.MethodGet, platform.GET_NOTIFICATIONS_ENDPOINT, (*Server).handleNotifications},
+}
+
+// A request received by the fake, as recorded by Requests.
+type Request struct {
+	Method     string
+	Endpoint   string // the matched endpoint constant, e.g. platform.GET_SUBMISSION_ENDPOINT
+	Path       string
+	OnBehalfOf string // onbehalfof header of logins, empty otherwise
+	StatusCode int
+}
+
+type failure struct {
+	status     int
+	retryAfter time.Duration
+	remaining  int
+}
+
+// Server is a fake of the MyInvois platform. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	clientId     string
+	clientSecret string
+	tokenTTL     time.Duration
+	clock        func() time.Time
+
+	mu              sync.Mutex
+	offset          time.Duration // added to clock by Advance
+	seq             int
+	tokens          map[string]time.Time // access token -> expiry
+	failures        map[string][]*failure
+	requests        []Request
+	processingPolls int
+	rejectChecks    []Check
+	invalidChecks   []Check
+	submissions     map[string]*submission
+	documents       map[string]*Document
+	documentOrder   []string // UUIDs, oldest first
+	taxpayers       map[string]taxpayer
+	notifications   []platform.Notification
+}
+
+type Option func(*Server)
+
+// Only accept logins with the given credentials.
+// The default is "clientId" and "clientSecret".
+func WithCredentials(clientId string, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientId = clientId
+		s.clientSecret = clientSecret
+	}
+}
+
+// Issue access tokens valid for ttl. The default is an hour.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// Use clock instead of time.Now as the time of the platform.
+func WithClock(clock func() time.Time) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Start a fake platform. Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		clientId:     "clientId",
+		clientSecret: "clientSecret",
+		tokenTTL:     time.Hour,
+		clock:        time.Now,
+		tokens:       make(map[string]time.Time),
+		failures:     make(map[string][]*failure),
+		submissions:  make(map[string]*submission),
+		documents:    make(map[string]*Document),
+		taxpayers:    make(map[string]taxpayer),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Create a client pointed at the fake, logging in with the default
+// credentials. Client side rate limiting is disabled and retries back off
+// for at most a few milliseconds; opts are applied after that.
+func (s *Server) NewApi(opts ...platform.ApiOption) *platform.Api {
+	defaults := []platform.ApiOption{
+		platform.WithApiBaseUrl(s.URL),
+		platform.WithIdentityBaseUrl(s.URL),
+		platform.WithRateLimits(nil),
+		platform.WithRetryPolicy(platform.RetryPolicy{
+			MaxAttempts: platform.DefaultRetryPolicy.MaxAttempts,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    10 * time.Millisecond,
+		}),
+	}
+
+	return platform.NewApi(s.clientId, s.clientSecret, append(defaults, opts...)...)
+}
+
+// The current time of the platform.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now()
+}
+
+func (s *Server) now() time.Time {
+	return s.clock().Add(s.offset).UTC()
+}
+
+// Move the time of the platform forward, e.g. past the cancellation window
+// or the expiry of the access tokens.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// Respond to the next n requests to endpoint with status instead of
+// handling them. Logins count as requests to platform.TAXPAYER_LOGIN_ENDPOINT.
+func (s *Server) FailNext(endpoint string, status int, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = append(s.failures[endpoint], &failure{status: status, remaining: n})
+}
+
+// Respond to the next n requests to endpoint with 429 and the given
+// Retry-After, rounded up to whole seconds.
+func (s *Server) RateLimitNext(endpoint string, n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = append(s.failures[endpoint], &failure{
+		status:     http.StatusTooManyRequests,
+		retryAfter: retryAfter,
+		remaining:  n,
+	})
+}
+
+// Revoke every access token issued so far, as if they had expired. The next
+// request of every client is answered with 401.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = make(map[string]time.Time)
+}
+
+// Every request received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// How many requests to endpoint were received so far.
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, req := range s.requests {
+		if req.Endpoint == endpoint {
+			n++
+		}
+	}
+
+	return n
+}
+
+// generate a unique ID of the given length, e.g. for UUIDs and tokens
+func (s *Server) nextId(prefix string, length int) string {
+	s.seq++
+
+	id := fmt.Sprintf("%s%d", prefix, s.seq)
+	if len(id) < length {
+		id = prefix + strings.Repeat("0", length-len(id)) + id[len(prefix):]
+	}
+
+	return id
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("correlationId", fmt.Sprintf("fake-%d", time.Now().UnixNano()))
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+	}
+
+	defer func() {
+		req.StatusCode = rec.status
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+	}()
+
+	for _, e := range endpoints {
+		param, ok := match(e.endpoint, r.URL.Path)
+		if !ok || r.Method != e.method {
+			continue
+		}
+
+		req.Endpoint = e.endpoint
+
+		if e.endpoint == platform.TAXPAYER_LOGIN_ENDPOINT {
+			req.OnBehalfOf = r.Header.Get("onbehalfof")
+		}
+
+		if s.fail(rec, e.endpoint) {
+			return
+		}
+
+		if e.endpoint != platform.TAXPAYER_LOGIN_ENDPOINT && !s.authorized(r) {
+			writeError(rec, http.StatusUnauthorized, "Unauthorized", "the access token is missing, invalid or expired")
+			return
+		}
+
+		e.handle(s, rec, r, param)
+
+		return
+	}
+
+	writeError(rec, http.StatusNotFound, "NotFound", fmt.Sprintf("no such endpoint: %s %s", r.Method, r.URL.Path))
+}
+
+// respond with a scripted failure, if any is pending for endpoint
+func (s *Server) fail(w http.ResponseWriter, endpoint string) bool {
+	s.mu.Lock()
+
+	pending := s.failures[endpoint]
+	if len(pending) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+
+	f := *pending[0]
+
+	pending[0].remaining--
+	if pending[0].remaining <= 0 {
+		s.failures[endpoint] = pending[1:]
+	}
+
+	s.mu.Unlock()
+
+	if f.status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", (f.retryAfter+time.Second-1)/time.Second))
+	}
+
+	writeError(w, f.status, http.StatusText(f.status), "scripted failure")
+
+	return true
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.tokens[token]
+
+	return ok && s.now().Before(expiresAt)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request, _ string) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	if r.PostForm.Get("client_id") != s.clientId || r.PostForm.Get("client_secret") != s.clientSecret {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	token := s.nextId("token-", 0)
+	s.tokens[token] = s.now().Add(s.tokenTTL)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, platform.TaxPayerLoginResponse{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		ExpiresInSec: int(s.tokenTTL / time.Second),
+		Scope:        r.PostForm.Get("scope"),
+	})
+}
+
+// match path against an endpoint constant, returning the value of its
+// placeholder, if any
+func match(endpoint string, path string) (string, bool) {
+	want := strings.Split(strings.TrimSuffix(endpoint, "/"), "/")
+	got := strings.Split(strings.TrimSuffix(path, "/"), "/")
+
+	if len(want) != len(got) {
+		return "", false
+	}
+
+	param := ""
+
+	for i := range want {
+		if strings.HasPrefix(want[i], "{") && strings.HasSuffix(want[i], "}") {
+			if got[i] == "" {
+				return "", false
+			}
+
+			param = got[i]
+			continue
+		}
+
+		if want[i] != got[i] {
+			return "", false
+		}
+	}
+
+	return param, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Reference: https://sdk.myinvois.hasil.gov.my/standard-error-response/
+func writeError(w http.ResponseWriter, status int, code string, message string, inner ...common.ErrResponse) {
+	writeJSON(w, status, common.StandardErrResponse{
+		Error: common.ErrResponse{
+			ErrorCode:    code,
+			ErrorMessage: message,
+			InnerErrors:  inner,
+		},
+	})
+}
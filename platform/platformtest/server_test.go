@@ -0,0 +1,438 @@
+package platformtest
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/common"
+	"github.com/programmer-my/einvoice-go/platform"
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+func newTestServer(t *testing.T) *Server {
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// an invoice issued an hour before now, or at issuedAt if given
+func testInvoice(id string, issuedAt ...time.Time) platform.Document {
+	at := time.Now().UTC().Add(-time.Hour)
+	if len(issuedAt) > 0 {
+		at = issuedAt[0].UTC()
+	}
+
+	raw := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+	<cbc:ID>%s</cbc:ID>
+	<cbc:IssueDate>%s</cbc:IssueDate>
+	<cbc:IssueTime>%s</cbc:IssueTime>
+	<cbc:InvoiceTypeCode listVersionID="1.1">01</cbc:InvoiceTypeCode>
+	<cbc:DocumentCurrencyCode>MYR</cbc:DocumentCurrencyCode>
+	<cac:AccountingSupplierParty>
+		<cac:Party>
+			<cac:PartyIdentification><cbc:ID schemeID="TIN">C2584563200</cbc:ID></cac:PartyIdentification>
+			<cac:PartyLegalEntity><cbc:RegistrationName>Supplier Sdn. Bhd.</cbc:RegistrationName></cac:PartyLegalEntity>
+		</cac:Party>
+	</cac:AccountingSupplierParty>
+	<cac:AccountingCustomerParty>
+		<cac:Party>
+			<cac:PartyIdentification><cbc:ID schemeID="TIN">C1234567890</cbc:ID></cac:PartyIdentification>
+			<cac:PartyLegalEntity><cbc:RegistrationName>Buyer Sdn. Bhd.</cbc:RegistrationName></cac:PartyLegalEntity>
+		</cac:Party>
+	</cac:AccountingCustomerParty>
+	<cac:LegalMonetaryTotal>
+		<cbc:LineExtensionAmount currencyID="MYR">100.00</cbc:LineExtensionAmount>
+		<cbc:TaxExclusiveAmount currencyID="MYR">100.00</cbc:TaxExclusiveAmount>
+		<cbc:TaxInclusiveAmount currencyID="MYR">106.00</cbc:TaxInclusiveAmount>
+		<cbc:PayableAmount currencyID="MYR">106.00</cbc:PayableAmount>
+	</cac:LegalMonetaryTotal>
+	<cac:InvoiceLine>
+		<cbc:ID>1</cbc:ID>
+		<cbc:LineExtensionAmount currencyID="MYR">100.00</cbc:LineExtensionAmount>
+	</cac:InvoiceLine>
+</Invoice>`, id, at.Format("2006-01-02"), at.Format("15:04:05Z"))
+
+	return platform.NewDocument(platform.FORMAT_XML, id, []byte(raw))
+}
+
+func TestSubmitJSON(t *testing.T) {
+	server := newTestServer(t)
+	api := server.NewApi()
+	ctx := context.Background()
+
+	raw, _ := base64.StdEncoding.DecodeString(testInvoice("INV-1").Document)
+	inv, err := ubl.ParseInvoice(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	doc, err := platform.NewJSONDocument(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := api.SubmitDocument(ctx, []platform.Document{doc})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(resp.AcceptedDocuments) != 1 {
+		t.Fatalf("expected 1 accepted document, got %d: %+v", len(resp.AcceptedDocuments), resp.RejectedDocuments)
+	}
+
+	result, err := api.WaitForSubmission(ctx, resp.SubmissionUID, &platform.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if platform.NormalizeSubmissionStatus(result.OverallStatus) != platform.SUBMISSION_VALID {
+		t.Errorf("expected overall status to be %s, got %s", platform.SUBMISSION_VALID, result.OverallStatus)
+	}
+}
+
+func TestSubmitAndWaitForSubmission(t *testing.T) {
+	server := newTestServer(t)
+	server.SetProcessingPolls(2)
+	server.InvalidateWhen(func(doc *Document) *common.ErrResponse {
+		if doc.CodeNumber == "INV-2" {
+			return &common.ErrResponse{ErrorCode: "CV002", ErrorMessage: "buyer is blacklisted"}
+		}
+
+		return nil
+	})
+
+	api := server.NewApi()
+	ctx := context.Background()
+
+	resp, err := api.SubmitDocument(ctx, []platform.Document{
+		testInvoice("INV-1"),
+		testInvoice("INV-2"),
+		testInvoice("INV-3", time.Now().AddDate(0, -1, 0)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(resp.AcceptedDocuments) != 3 {
+		t.Fatalf("expected 3 accepted documents, got %d", len(resp.AcceptedDocuments))
+	}
+
+	result, err := api.WaitForSubmission(ctx, resp.SubmissionUID, &platform.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if server.Calls(platform.GET_SUBMISSION_ENDPOINT) != 2 {
+		t.Errorf("expected 2 polls, got %d", server.Calls(platform.GET_SUBMISSION_ENDPOINT))
+	}
+
+	if platform.NormalizeSubmissionStatus(result.OverallStatus) != platform.SUBMISSION_PARTIALLY_VALID {
+		t.Errorf("expected overall status to be %s, got %s", platform.SUBMISSION_PARTIALLY_VALID, result.OverallStatus)
+	}
+
+	expected := []struct {
+		status string
+		step   string
+	}{
+		{STATUS_VALID, ""},
+		{STATUS_INVALID, "Step03-Custom Validator"},
+		{STATUS_INVALID, "Step02-Core Fields Validator"}, // issued too long ago
+	}
+
+	for i, doc := range result.Documents {
+		if doc.Status != expected[i].status {
+			t.Errorf("expected status of %s to be %s, got %s", doc.InternalId, expected[i].status, doc.Status)
+		}
+
+		if expected[i].step == "" {
+			if doc.ValidationResults != nil {
+				t.Errorf("expected no validation results for %s", doc.InternalId)
+			}
+			continue
+		}
+
+		failed := doc.ValidationResults.FailedSteps()
+		if len(failed) != 1 || failed[0].Name != expected[i].step {
+			t.Errorf("expected %s to fail %s, got %+v", doc.InternalId, expected[i].step, failed)
+		}
+	}
+
+	raw, err := api.GetDocument(ctx, resp.AcceptedDocuments[0].UUID)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	inv, err := raw.Invoice()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.ID != "INV-1" {
+		t.Errorf("expected invoice ID to be INV-1, got %s", inv.ID)
+	}
+
+	if raw.IssuerName != "Supplier Sdn. Bhd." || raw.TotalPayableAmount != "106.00" || raw.LongId == "" {
+		t.Errorf("unexpected document summary %+v", raw.SubmissionDocumentSummary)
+	}
+}
+
+func TestSubmitPartialRejection(t *testing.T) {
+	server := newTestServer(t)
+	server.RejectWhen(func(doc *Document) *common.ErrResponse {
+		if doc.CodeNumber == "INV-2" {
+			return &common.ErrResponse{ErrorCode: "BadArgument", ErrorMessage: "rejected by test"}
+		}
+
+		return nil
+	})
+
+	api := server.NewApi()
+	ctx := context.Background()
+
+	tampered := testInvoice("INV-3")
+	tampered.DocumentSHA256 = "00"
+
+	resp, err := api.SubmitDocument(ctx, []platform.Document{
+		testInvoice("INV-1"),
+		testInvoice("INV-2"),
+		tampered,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(resp.AcceptedDocuments) != 1 || resp.AcceptedDocuments[0].InvoiceCodeNumber != "INV-1" {
+		t.Errorf("expected only INV-1 to be accepted, got %+v", resp.AcceptedDocuments)
+	}
+
+	expectedErrors := map[string]string{"INV-2": "BadArgument", "INV-3": "IncorrectHash"}
+
+	if len(resp.RejectedDocuments) != len(expectedErrors) {
+		t.Fatalf("expected %d rejected documents, got %+v", len(expectedErrors), resp.RejectedDocuments)
+	}
+
+	for _, rejected := range resp.RejectedDocuments {
+		if rejected.Error.ErrorCode != expectedErrors[rejected.InvoiceCodeNumber] {
+			t.Errorf("expected %s to be rejected with %s, got %s", rejected.InvoiceCodeNumber, expectedErrors[rejected.InvoiceCodeNumber], rejected.Error.ErrorCode)
+		}
+	}
+
+	resp, err = api.SubmitDocument(ctx, []platform.Document{testInvoice("INV-1")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(resp.RejectedDocuments) != 1 || resp.RejectedDocuments[0].Error.ErrorCode != "DuplicateSubmission" {
+		t.Errorf("expected resubmission to be rejected as duplicate, got %+v", resp.RejectedDocuments)
+	}
+}
+
+func TestRateLimitAndTokenExpiry(t *testing.T) {
+	server := newTestServer(t)
+	api := server.NewApi()
+	ctx := context.Background()
+
+	server.RateLimitNext(platform.GET_NOTIFICATIONS_ENDPOINT, 2, 0)
+
+	if _, err := api.GetNotifications(ctx, &platform.NotificationsQuery{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if server.Calls(platform.GET_NOTIFICATIONS_ENDPOINT) != 3 {
+		t.Errorf("expected 3 requests, got %d", server.Calls(platform.GET_NOTIFICATIONS_ENDPOINT))
+	}
+
+	server.ExpireTokens()
+
+	if _, err := api.GetNotifications(ctx, &platform.NotificationsQuery{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if server.Calls(platform.TAXPAYER_LOGIN_ENDPOINT) != 2 {
+		t.Errorf("expected 2 logins, got %d", server.Calls(platform.TAXPAYER_LOGIN_ENDPOINT))
+	}
+
+	server.FailNext(platform.GET_NOTIFICATIONS_ENDPOINT, http.StatusBadRequest, 1)
+
+	_, err := api.GetNotifications(ctx, &platform.NotificationsQuery{})
+
+	var apiErr *platform.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 APIError, got %v", err)
+	}
+}
+
+func TestLoginWithWrongCredentials(t *testing.T) {
+	server := newTestServer(t)
+
+	api := platform.NewApi("clientId", "wrong", platform.WithApiBaseUrl(server.URL), platform.WithIdentityBaseUrl(server.URL))
+
+	_, err := api.GetNotifications(context.Background(), &platform.NotificationsQuery{})
+
+	var apiErr *platform.APIError
+	if !errors.As(err, &apiErr) || apiErr.Response.Error.ErrorCode != "invalid_client" {
+		t.Errorf("expected invalid_client, got %v", err)
+	}
+}
+
+func TestCancelAndReject(t *testing.T) {
+	server := newTestServer(t)
+	api := server.NewApi()
+	ctx := context.Background()
+
+	resp, err := api.SubmitDocument(ctx, []platform.Document{
+		testInvoice("INV-1"),
+		testInvoice("INV-2"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	first, second := resp.AcceptedDocuments[0].UUID, resp.AcceptedDocuments[1].UUID
+
+	cancelled, err := api.CancelDocument(ctx, first, "wrong buyer", platform.WithPrecheck())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cancelled.Status != "Cancelled" {
+		t.Errorf("expected status to be Cancelled, got %s", cancelled.Status)
+	}
+
+	if doc, _ := server.Document(first); doc.Status != STATUS_CANCELLED || doc.StatusReason != "wrong buyer" {
+		t.Errorf("expected document to be cancelled, got %s %q", doc.Status, doc.StatusReason)
+	}
+
+	if _, err := api.CancelDocument(ctx, first, "again"); !platform.IsValidationError(err) && !isBadRequest(err) {
+		t.Errorf("expected cancelling twice to fail, got %v", err)
+	}
+
+	server.Advance(platform.STATE_CHANGE_WINDOW + time.Hour)
+
+	_, err = api.RejectDocument(ctx, second, "wrong amount")
+
+	var apiErr *platform.APIError
+	if !errors.As(err, &apiErr) || apiErr.Response.Error.ErrorCode != "OperationPeriodOver" {
+		t.Errorf("expected OperationPeriodOver, got %v", err)
+	}
+
+	var types []string
+	for _, n := range server.Notifications() {
+		types = append(types, n.TypeId)
+	}
+
+	expectedTypes := fmt.Sprint([]string{
+		platform.NOTIFICATION_DOCUMENT_RECEIVED,
+		platform.NOTIFICATION_DOCUMENT_VALIDATED,
+		platform.NOTIFICATION_DOCUMENT_VALIDATED,
+		platform.NOTIFICATION_DOCUMENT_CANCELLED,
+	})
+
+	if fmt.Sprint(types) != expectedTypes {
+		t.Errorf("expected notifications %s, got %s", expectedTypes, fmt.Sprint(types))
+	}
+}
+
+func isBadRequest(err error) bool {
+	var apiErr *platform.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+func TestSearchAndRecentDocuments(t *testing.T) {
+	server := newTestServer(t)
+	api := server.NewApi()
+	ctx := context.Background()
+
+	var docs []platform.Document
+	for i := 1; i <= 5; i++ {
+		docs = append(docs, testInvoice(fmt.Sprintf("INV-%d", i)))
+	}
+
+	if _, err := api.SubmitDocument(ctx, docs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	from := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	search := platform.NewSearchDocumentsIterator(api, platform.SearchDocumentsQuery{SubmissionDateFrom: &from, PageSize: 2})
+
+	var found []string
+	for search.Next(ctx) {
+		found = append(found, search.Document().InternalId)
+	}
+
+	if err := search.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if fmt.Sprint(found) != "[INV-5 INV-4 INV-3 INV-2 INV-1]" {
+		t.Errorf("expected every document newest first, got %v", found)
+	}
+
+	if server.Calls(platform.SEARCH_DOCUMENTS_ENDPOINT) != 3 {
+		t.Errorf("expected 3 pages, got %d", server.Calls(platform.SEARCH_DOCUMENTS_ENDPOINT))
+	}
+
+	query := "INV-3"
+	resp, err := api.SearchDocuments(ctx, &platform.SearchDocumentsQuery{SubmissionDateFrom: &from, SearchQuery: &query})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(resp.Result) != 1 || resp.Result[0].BuyerName != "Buyer Sdn. Bhd." {
+		t.Errorf("expected to find INV-3, got %+v", resp.Result)
+	}
+
+	if _, err := api.SearchDocuments(ctx, &platform.SearchDocumentsQuery{}); !isBadRequest(err) {
+		t.Errorf("expected search without a date range to fail, got %v", err)
+	}
+
+	status := "Valid"
+	recent, err := api.GetRecentDocuments(ctx, &platform.GetRecentDocumentsQuery{Status: &status})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if recent.Metadata.TotalCount != 5 || len(recent.Result) != 5 || recent.Result[0].Total != "106.00" {
+		t.Errorf("unexpected recent documents %+v", recent)
+	}
+}
+
+func TestValidateTIN(t *testing.T) {
+	server := newTestServer(t)
+	server.AddTaxpayer("C2584563200", platform.ID_BRN, "201901234567")
+
+	api := server.NewApi()
+	ctx := context.Background()
+
+	tests := []struct {
+		tin      string
+		idValue  string
+		expected bool
+	}{
+		{"C2584563200", "201901234567", true},
+		{"C2584563200", "201901234568", false},
+		{"C0000000000", "201901234567", false},
+	}
+
+	for _, test := range tests {
+		valid, err := api.ValidateTIN(ctx, test.tin, platform.ID_BRN, test.idValue)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if valid != test.expected {
+			t.Errorf("expected %s %s to be %t, got %t", test.tin, test.idValue, test.expected, valid)
+		}
+	}
+
+	if _, err := api.ValidateTIN(ctx, "C2584563200", platform.ID_BRN, ""); !isBadRequest(err) {
+		t.Errorf("expected missing idValue to fail, got %v", err)
+	}
+}
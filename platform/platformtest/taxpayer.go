@@ -0,0 +1,157 @@
+package platformtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/platform"
+)
+
+type taxpayer struct {
+	idType  platform.TinIdType
+	idValue string
+}
+
+// Register a taxpayer, so that ValidateTIN succeeds for its TIN and ID.
+// Every other TIN is reported as not found.
+func (s *Server) AddTaxpayer(tin string, idType platform.TinIdType, idValue string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taxpayers[tin] = taxpayer{idType: idType, idValue: idValue}
+}
+
+func (s *Server) handleValidateTIN(w http.ResponseWriter, r *http.Request, tin string) {
+	idType := r.URL.Query().Get("idType")
+	idValue := r.URL.Query().Get("idValue")
+
+	if idType == "" || idValue == "" {
+		writeError(w, http.StatusBadRequest, "BadArgument", "idType and idValue are required")
+		return
+	}
+
+	s.mu.Lock()
+	t, ok := s.taxpayers[tin]
+	s.mu.Unlock()
+
+	if !ok || !strings.EqualFold(string(t.idType), idType) || t.idValue != idValue {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("TIN %s does not match %s %s", tin, idType, idValue))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Add a notification for the taxpayer. Notifications are also added when
+// documents are received, validated, cancelled or rejected. The ID and the
+// received time are filled in when empty.
+func (s *Server) Notify(n platform.Notification) platform.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n.Notificationid == "" {
+		n.Notificationid = s.nextId("NTF", 26)
+	}
+
+	if n.ReceivedDateTime == "" {
+		n.ReceivedDateTime = formatTime(s.now())
+	}
+
+	s.notifications = append(s.notifications, n)
+
+	return n
+}
+
+// Every notification so far, oldest first.
+func (s *Server) Notifications() []platform.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]platform.Notification(nil), s.notifications...)
+}
+
+func (s *Server) notify(typeId string, typeName string, message string) {
+	s.notifications = append(s.notifications, platform.Notification{
+		Notificationid:   s.nextId("NTF", 26),
+		ReceivedDateTime: formatTime(s.now()),
+		TypeId:           typeId,
+		TypeName:         typeName,
+		FinalMessage:     message,
+		Channel:          "push",
+		Language:         "en",
+		Status:           "delivered",
+	})
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request, _ string) {
+	query := r.URL.Query()
+
+	var from, to time.Time
+
+	for _, t := range []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"dateFrom", &from},
+		{"dateTo", &to},
+	} {
+		value := query.Get(t.param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadArgument", fmt.Sprintf("invalid %s %q", t.param, value))
+			return
+		}
+
+		*t.dst = parsed
+	}
+
+	s.mu.Lock()
+
+	var found []platform.Notification
+	for _, n := range s.notifications {
+		receivedAt, _ := n.ReceivedAt()
+
+		if !inRange(receivedAt, from, to) {
+			continue
+		}
+
+		if typeId := query.Get("type"); typeId != "" && n.TypeId != typeId {
+			continue
+		}
+
+		if status := query.Get("status"); status != "" && !strings.EqualFold(n.Status, status) {
+			continue
+		}
+
+		if channel := query.Get("channel"); channel != "" && !strings.EqualFold(n.Channel, channel) {
+			continue
+		}
+
+		if language := query.Get("language"); language != "" && !strings.EqualFold(n.Language, language) {
+			continue
+		}
+
+		found = append(found, n)
+	}
+
+	s.mu.Unlock()
+
+	found, meta, ok := page(w, r, found, platform.GET_NOTIFICATIONS_MAX_PAGE_SIZE)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, platform.GetNotificationsResponse{
+		Result: found,
+		Metadata: platform.NotificationMetadata{
+			TotalPages: int(meta.TotalPages),
+			TotalCount: int(meta.TotalCount),
+		},
+	})
+}
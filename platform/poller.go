@@ -0,0 +1,166 @@
+package platform
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Values of the overall status of a submission, lowercased and without spaces
+const (
+	SUBMISSION_IN_PROGRESS     = "inprogress"
+	SUBMISSION_VALID           = "valid"
+	SUBMISSION_PARTIALLY_VALID = "partiallyvalid"
+	SUBMISSION_INVALID         = "invalid"
+)
+
+type WaitOptions struct {
+	PollInterval time.Duration // delay between the first polls, doubled after every poll
+	MaxInterval  time.Duration // upper bound of the delay between polls
+	PageSize     int64         // page size used to fetch the document summaries, capped at GET_SUBMISSION_MAX_PAGE_SIZE
+
+	// Do not fetch the validation results of invalid documents
+	SkipValidationResults bool
+
+	// Called with the first page of the submission after every poll
+	OnPoll func(resp *GetSubmissionResponse)
+}
+
+// The platform recommends polling a submission every 3 to 5 seconds.
+var DefaultWaitOptions = WaitOptions{
+	PollInterval: 3 * time.Second,
+	MaxInterval:  30 * time.Second,
+	PageSize:     GET_SUBMISSION_MAX_PAGE_SIZE,
+}
+
+type SubmissionResult struct {
+	SubmissionUid string
+	OverallStatus string
+	Documents     []SubmissionDocumentResult // every document in the submission
+}
+
+type SubmissionDocumentResult struct {
+	SubmissionDocumentSummary
+	ValidationResults *DocumentValidationResults // only for invalid documents
+}
+
+// Normalise the overall status of a submission to one of the SUBMISSION_
+// constants. The platform is not consistent about casing and spacing.
+func NormalizeSubmissionStatus(status string) string {
+	return strings.ToLower(strings.ReplaceAll(status, " ", ""))
+}
+
+// WaitForSubmission polls a submission until it is no longer in progress,
+// then returns the status of every document in it, along with the
+// validation results of the invalid ones.
+//
+// The delay between polls starts at opts.PollInterval and doubles up to
+// opts.MaxInterval. Pass nil to use DefaultWaitOptions. Use ctx to bound
+// how long to wait.
+func (a *Api) WaitForSubmission(ctx context.Context, submissionUid string, opts *WaitOptions) (*SubmissionResult, error) {
+	o := DefaultWaitOptions
+	if opts != nil {
+		o = *opts
+
+		if o.PollInterval <= 0 {
+			o.PollInterval = DefaultWaitOptions.PollInterval
+		}
+
+		if o.MaxInterval < o.PollInterval {
+			o.MaxInterval = o.PollInterval
+		}
+	}
+
+	o.PageSize = pageSize(o.PageSize, GET_SUBMISSION_MAX_PAGE_SIZE)
+
+	interval := o.PollInterval
+
+	for {
+		resp, err := a.GetSubmission(ctx, submissionUid, &GetSubmissionQuery{PageNo: 1, PageSize: o.PageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		if o.OnPoll != nil {
+			o.OnPoll(resp)
+		}
+
+		if NormalizeSubmissionStatus(resp.OverallStatus) != SUBMISSION_IN_PROGRESS {
+			return a.collectSubmission(ctx, resp, o)
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+
+		interval *= 2
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
+
+// collect the remaining pages of a processed submission and the validation
+// results of its invalid documents
+func (a *Api) collectSubmission(ctx context.Context, first *GetSubmissionResponse, o WaitOptions) (*SubmissionResult, error) {
+	summaries := first.DocumentSummary
+
+	for pageNo := int64(2); int64(len(summaries)) < first.DocumentCount; pageNo++ {
+		resp, err := a.GetSubmission(ctx, first.SubmissionUid, &GetSubmissionQuery{PageNo: pageNo, PageSize: o.PageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.DocumentSummary) == 0 {
+			break
+		}
+
+		summaries = append(summaries, resp.DocumentSummary...)
+	}
+
+	result := SubmissionResult{
+		SubmissionUid: first.SubmissionUid,
+		OverallStatus: first.OverallStatus,
+		Documents:     make([]SubmissionDocumentResult, len(summaries)),
+	}
+
+	valid, invalid := 0, 0
+
+	for i, summary := range summaries {
+		result.Documents[i].SubmissionDocumentSummary = summary
+
+		switch {
+		case strings.EqualFold(summary.Status, "Valid"):
+			valid++
+		case strings.EqualFold(summary.Status, "Invalid"):
+			invalid++
+		}
+
+		if o.SkipValidationResults || !strings.EqualFold(summary.Status, "Invalid") {
+			continue
+		}
+
+		details, err := a.GetDocumentDetails(ctx, summary.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Documents[i].ValidationResults = &details.ValidationResults
+
+		for _, step := range details.ValidationResults.FailedSteps() {
+			args := []any{"uuid", summary.UUID, "internalId", summary.InternalId, "step", step.Name}
+			if step.Error != nil {
+				args = append(args, "errorCode", step.Error.ErrorCode, "error", step.Error.ErrorMessage)
+			}
+
+			a.logger.Warn("document invalid", args...)
+		}
+	}
+
+	a.metrics.ObserveDocuments(DOCUMENT_VALID, valid)
+	a.metrics.ObserveDocuments(DOCUMENT_INVALID, invalid)
+
+	a.logger.Info("submission processed", "submissionUid", first.SubmissionUid, "status", first.OverallStatus, "valid", valid, "invalid", invalid)
+
+	return &result, nil
+}
@@ -0,0 +1,103 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForSubmission(t *testing.T) {
+	polls := 0
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/details") {
+			w.Write([]byte(`{"uuid":"C","status":"Invalid","validationResults":{"status":"Invalid","validationSteps":[{"name":"Step03-Duplicated Submission Validator","status":"Invalid","error":{"errorCode":"DS302","error":"Duplicate"}}]}}`))
+			return
+		}
+
+		if r.URL.Query().Get("pageSize") != "2" {
+			t.Errorf("expected page size to be 2, got %s", r.URL.Query().Get("pageSize"))
+		}
+
+		if r.URL.Query().Get("pageNo") == "1" {
+			polls++
+		}
+
+		if polls < 3 {
+			w.Write([]byte(`{"submissionUid":"S1","documentCount":3,"overallStatus":"in progress","documentSummary":[]}`))
+			return
+		}
+
+		switch r.URL.Query().Get("pageNo") {
+		case "1":
+			w.Write([]byte(`{"submissionUid":"S1","documentCount":3,"overallStatus":"partially valid","documentSummary":[{"uuid":"A","status":"Valid","totalPayableAmount":10.10},{"uuid":"B","status":"Valid"}]}`))
+		case "2":
+			w.Write([]byte(`{"submissionUid":"S1","documentCount":3,"overallStatus":"partially valid","documentSummary":[{"uuid":"C","status":"Invalid"}]}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("pageNo"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	var statuses []string
+
+	result, err := api.WaitForSubmission(context.Background(), "S1", &WaitOptions{
+		PollInterval: time.Millisecond,
+		MaxInterval:  2 * time.Millisecond,
+		PageSize:     2,
+		OnPoll: func(resp *GetSubmissionResponse) {
+			statuses = append(statuses, resp.OverallStatus)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(statuses) != 3 || statuses[2] != "partially valid" {
+		t.Errorf("expected 3 polls ending with partially valid, got %v", statuses)
+	}
+
+	if NormalizeSubmissionStatus(result.OverallStatus) != SUBMISSION_PARTIALLY_VALID {
+		t.Errorf("expected overall status to be partially valid, got %s", result.OverallStatus)
+	}
+
+	if len(result.Documents) != 3 {
+		t.Fatalf("expected 3 documents, got %d", len(result.Documents))
+	}
+
+	if result.Documents[0].ValidationResults != nil {
+		t.Errorf("expected no validation results for a valid document")
+	}
+
+	invalid := result.Documents[2]
+	if invalid.UUID != "C" || invalid.ValidationResults == nil {
+		t.Fatalf("expected validation results for document C, got %+v", invalid)
+	}
+
+	if failed := invalid.ValidationResults.FailedSteps(); len(failed) != 1 || failed[0].Error.ErrorCode != "DS302" {
+		t.Errorf("expected failed step with DS302, got %+v", failed)
+	}
+}
+
+func TestWaitForSubmissionContextDeadline(t *testing.T) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"submissionUid":"S1","documentCount":1,"overallStatus":"InProgress"}`))
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := api.WaitForSubmission(ctx, "S1", &WaitOptions{PollInterval: 10 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
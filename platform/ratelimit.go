@@ -0,0 +1,109 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Requests per minute allowed by the platform for each endpoint, per client ID.
+// Reference: https://sdk.myinvois.hasil.gov.my/faq/#what-are-the-rate-limits-for-each-api
+//
+// Endpoints missing from this map are not throttled on the client side.
+var DefaultRateLimits = map[string]int{
+	TAXPAYER_LOGIN_ENDPOINT:       12, // also INTERM_LOGIN_ENDPOINT
+	SUBMIT_DOCUMENT_ENDPOINT:      100,
+	GET_SUBMISSION_ENDPOINT:       300,
+	CANCEL_DOCUMENT_ENDPOINT:      12, // also REJECT_DOCUMENT_ENDPOINT
+	GET_DOCUMENT_ENDPOINT:         60,
+	GET_DOCUMENT_DETAILS_ENDPOINT: 125,
+	GET_RECENT_DOCUMENTS_ENDPOINT: 12,
+	SEARCH_DOCUMENTS_ENDPOINT:     12,
+	VALIDATE_TIN_ENDPOINT:         60,
+	GET_NOTIFICATIONS_ENDPOINT:    12,
+}
+
+// tokenBucket allows bursts of up to capacity requests and refills
+// continuously at a fixed rate.
+type tokenBucket struct {
+	mu           sync.Mutex
+	capacity     float64
+	tokens       float64
+	refillPerSec float64
+	last         time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{
+		capacity:     float64(perMinute),
+		tokens:       float64(perMinute),
+		refillPerSec: float64(perMinute) / 60,
+		last:         time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait
+// before the token may be used.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.refillPerSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.refillPerSec * float64(time.Second))
+}
+
+// rateLimiter keeps one token bucket per endpoint. It is shared by every
+// client derived from the same Api, since the limits apply per client ID.
+type rateLimiter struct {
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limits map[string]int) *rateLimiter {
+	l := rateLimiter{
+		buckets: make(map[string]*tokenBucket, len(limits)),
+	}
+
+	for endpoint, perMinute := range limits {
+		if perMinute > 0 {
+			l.buckets[endpoint] = newTokenBucket(perMinute)
+		}
+	}
+
+	return &l
+}
+
+// Wait blocks until a request to endpoint is allowed or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context, endpoint string) error {
+	bucket, ok := l.buckets[endpoint]
+	if !ok {
+		return nil
+	}
+
+	return sleep(ctx, bucket.reserve(time.Now()))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package platform
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int           // including the first attempt. 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled on every further retry
+	MaxDelay    time.Duration // upper bound of the backoff delay
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Override the retry policy applied to 429 and 5xx responses.
+func WithRetryPolicy(policy RetryPolicy) ApiOption {
+	return func(a *Api) {
+		a.retryPolicy = policy
+	}
+}
+
+// Override the client side rate limits, in requests per minute per endpoint.
+// Pass nil to disable client side rate limiting.
+func WithRateLimits(limits map[string]int) ApiOption {
+	return func(a *Api) {
+		a.limiter = newRateLimiter(limits)
+	}
+}
+
+// Exponential backoff with full jitter.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	// compared before shifting, as the shift overflows on later retries
+	delay := p.MaxDelay
+	if shift := retry - 1; shift < 63 && p.BaseDelay <= p.MaxDelay>>shift {
+		delay = p.BaseDelay << shift
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// Whether a request that ended with the given status (0 for a transport
+// error) may be sent again.
+//
+// A 429 means the request was turned away before being processed, so it is
+// always safe to retry. Anything else is only retried for GET requests: a
+// submission that failed halfway may already have been accepted, and
+// sending it again would be rejected as a duplicate at best. Likewise a
+// cancellation or rejection whose response was lost has already changed
+// the state of the document, so sending it again fails with an invalid
+// state error.
+func isRetryable(req *http.Request, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	switch status {
+	case 0, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return req.Method == http.MethodGet
+	}
+
+	return false
+}
+
+// Parse the Retry-After header, which can be either in seconds or a date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// send performs req while respecting the client side rate limit of endpoint,
+// retrying on rate limiting and transient failures according to the retry
+// policy. The response is returned along with its fully read body.
+func (a *Api) send(endpoint string, req *http.Request) (*http.Response, []byte, error) {
+	if a.err != nil {
+		return nil, nil, a.err
+	}
+
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		if err := a.limiter.Wait(ctx, endpoint); err != nil {
+			return nil, nil, err
+		}
+
+		sentAt := time.Now()
+		resp, respBytes, err := a.roundTrip(req)
+
+		a.observe(endpoint, req, attempt, sentAt, resp, respBytes, err)
+
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+
+		if attempt >= a.retryPolicy.MaxAttempts || !isRetryable(req, status) || (err == nil && status < 400) {
+			return resp, respBytes, err
+		}
+
+		delay := a.retryPolicy.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				delay = after
+				if delay > a.retryPolicy.MaxDelay {
+					delay = a.retryPolicy.MaxDelay
+				}
+			}
+		}
+
+		// no point in waiting for a retry that cannot be made in time
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, respBytes, err
+		}
+
+		a.logger.Info("retrying platform request", "method", req.Method, "endpoint", endpoint, "attempt", attempt+1, "delay", delay)
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+
+		if err := rewind(req); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (a *Api) roundTrip(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request to API failed: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, respBytes, nil
+}
+
+// rewind resets the body of req so it can be sent again.
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	if req.GetBody == nil {
+		return fmt.Errorf("request body of %s %s cannot be replayed", req.Method, req.URL.Path)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+
+	req.Body = body
+
+	return nil
+}
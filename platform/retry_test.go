@@ -0,0 +1,254 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+}
+
+func newRetryTestApi(statuses []int, calls *int64) (*Api, func()) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(calls, 1)
+
+		if int(n) <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"submissionUid":"HJSD135P2S7D8IU"}`))
+			return
+		}
+
+		w.Write([]byte(`{"result":[]}`))
+	}))
+
+	api := NewApi("clientId", "clientSecret",
+		WithApiBaseUrl(server.URL),
+		WithIdentityBaseUrl(server.URL),
+		WithRetryPolicy(testRetryPolicy),
+		WithRateLimits(nil),
+	)
+
+	return api, server.Close
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	var calls int64
+	api, done := newRetryTestApi([]int{http.StatusTooManyRequests, http.StatusServiceUnavailable}, &calls)
+	defer done()
+
+	if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected %d calls, got %d", 3, calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int64
+	api, done := newRetryTestApi([]int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, &calls)
+	defer done()
+
+	api.GetDocumentTypes(context.Background())
+
+	if calls != int64(testRetryPolicy.MaxAttempts) {
+		t.Errorf("expected %d calls, got %d", testRetryPolicy.MaxAttempts, calls)
+	}
+}
+
+func TestRetrySubmissionOnlyWhenRateLimited(t *testing.T) {
+	docs := []Document{NewDocument(FORMAT_XML, "INV-0001", []byte("<Invoice/>"))}
+
+	var calls int64
+	api, done := newRetryTestApi([]int{http.StatusTooManyRequests}, &calls)
+	defer done()
+
+	if _, err := api.SubmitDocument(context.Background(), docs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected %d calls after 429, got %d", 2, calls)
+	}
+
+	calls = 0
+	api, done = newRetryTestApi([]int{http.StatusServiceUnavailable}, &calls)
+	defer done()
+
+	if _, err := api.SubmitDocument(context.Background(), docs); err == nil {
+		t.Errorf("expected error after 503, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected %d call after 503, got %d", 1, calls)
+	}
+}
+
+func TestRetryStateChangeOnlyWhenRateLimited(t *testing.T) {
+	var calls int64
+	api, done := newRetryTestApi([]int{http.StatusServiceUnavailable}, &calls)
+	defer done()
+
+	// the cancellation may have gone through before the response was lost
+	if _, err := api.CancelDocument(context.Background(), "F9D425P6DS7D8IU", "Wrong buyer"); err == nil {
+		t.Errorf("expected error after 503, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected %d call after 503, got %d", 1, calls)
+	}
+}
+
+func TestRetryBackoffDoesNotOverflow(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 1000, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+	for _, retry := range []int{1, 10, 40, 63, 64, 100, 1000} {
+		var longest time.Duration
+		for i := 0; i < 100; i++ {
+			delay := policy.backoff(retry)
+			if delay < 0 || delay > policy.MaxDelay {
+				t.Fatalf("retry %d: expected delay between 0 and %s, got %s", retry, policy.MaxDelay, delay)
+			}
+
+			if delay > longest {
+				longest = delay
+			}
+		}
+
+		if retry >= 10 && longest < time.Second {
+			t.Errorf("retry %d: expected delays up to %s, got at most %s", retry, policy.MaxDelay, longest)
+		}
+	}
+}
+
+func newRetryAfterTestApi(retryAfter string, calls *int64) (*Api, func()) {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(calls, 1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Write([]byte(`{"result":[]}`))
+	}))
+
+	api := NewApi("clientId", "clientSecret",
+		WithApiBaseUrl(server.URL),
+		WithIdentityBaseUrl(server.URL),
+		WithRetryPolicy(testRetryPolicy),
+		WithRateLimits(nil),
+	)
+
+	return api, server.Close
+}
+
+func TestRetryAfterCappedAtMaxDelay(t *testing.T) {
+	for _, value := range []string{"86400", time.Now().AddDate(1, 0, 0).UTC().Format(http.TimeFormat)} {
+		var calls int64
+		api, done := newRetryAfterTestApi(value, &calls)
+
+		start := time.Now()
+		if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+			t.Errorf("Retry-After %q: unexpected error: %s", value, err)
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Retry-After %q: expected the wait to be capped, took %s", value, elapsed)
+		}
+
+		if calls != 2 {
+			t.Errorf("Retry-After %q: expected %d calls, got %d", value, 2, calls)
+		}
+
+		done()
+	}
+}
+
+func TestRetryAfterBeyondDeadline(t *testing.T) {
+	var calls int64
+	api, done := newRetryAfterTestApi("30", &calls)
+	defer done()
+
+	api.retryPolicy.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.GetDocumentTypes(ctx)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected APIError with status 429, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to fail without waiting, took %s", elapsed)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected %d call, got %d", 1, calls)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", expected: 0, ok: false},
+		{value: "120", expected: 2 * time.Minute, ok: true},
+		{value: now.Add(30 * time.Second).Format(http.TimeFormat), expected: 30 * time.Second, ok: true},
+		{value: "soon", expected: 0, ok: false},
+	}
+
+	for _, test := range cases {
+		resp := http.Response{Header: make(http.Header)}
+		resp.Header.Set("Retry-After", test.value)
+
+		actual, ok := retryAfter(&resp, now)
+
+		if actual != test.expected || ok != test.ok {
+			t.Errorf("Retry-After %q: expected (%s, %t), got (%s, %t)", test.value, test.expected, test.ok, actual, ok)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bucket := newTokenBucket(12)
+	bucket.last = now
+
+	for i := 0; i < 12; i++ {
+		if wait := bucket.reserve(now); wait != 0 {
+			t.Fatalf("expected request %d to go through, got wait of %s", i, wait)
+		}
+	}
+
+	// 12 per minute refills one token every 5 seconds
+	if wait := bucket.reserve(now); wait != 5*time.Second {
+		t.Errorf("expected wait of %s, got %s", 5*time.Second, wait)
+	}
+
+	if wait := bucket.reserve(now.Add(5 * time.Second)); wait != 5*time.Second {
+		t.Errorf("expected wait of %s, got %s", 5*time.Second, wait)
+	}
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sessionPool holds one client per taxpayer an intermediary acts on behalf
+// of. It is shared by the root Api and every client derived from it.
+type sessionPool struct {
+	mu       sync.Mutex
+	sessions map[string]*Api // keyed by the taxpayer TIN
+}
+
+func newSessionPool() *sessionPool {
+	return &sessionPool{
+		sessions: make(map[string]*Api),
+	}
+}
+
+// ForTaxpayer returns a client that acts on behalf of the taxpayer with the
+// given TIN, using intermediary login with the credentials of this Api.
+//
+// Clients are pooled by TIN, so every call with the same TIN shares one
+// cached intermediary token. It is safe to call from multiple goroutines.
+//
+//	resp, err := api.ForTaxpayer("C2584563200").SubmitDocument(ctx, docs)
+func (a *Api) ForTaxpayer(tin string) *Api {
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	if session, ok := a.sessions.sessions[tin]; ok {
+		return session
+	}
+
+	session := *a
+	session.onBehalfOf = tin
+	session.tokens = newTokenSource(session.intermediaryToken)
+
+	a.sessions.sessions[tin] = &session
+
+	return &session
+}
+
+// ForgetTaxpayer drops the pooled client and its cached token for the given
+// TIN. Clients previously returned by ForTaxpayer keep working on their own.
+func (a *Api) ForgetTaxpayer(tin string) {
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	delete(a.sessions.sessions, tin)
+}
+
+// OnBehalfOf returns the TIN of the taxpayer this client acts for, or an
+// empty string when it acts as the taxpayer itself.
+func (a *Api) OnBehalfOf() string {
+	return a.onBehalfOf
+}
+
+// log in as an intermediary on behalf of a.onBehalfOf
+func (a *Api) intermediaryToken(ctx context.Context) (*accessToken, error) {
+	requestedAt := time.Now()
+
+	req := IntermLoginRequest{
+		OnBehalfOf:           a.onBehalfOf,
+		TaxPayerLoginRequest: *NewTaxPayerLoginRequest(a.clientId, a.clientSecret, []string{DEFAULT_LOGIN_SCOPE}),
+	}
+
+	resp, err := a.DoIntemediarySystemLogin(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in login response for %s: %s %s", a.onBehalfOf, resp.Error, resp.ErrorDescription)
+	}
+
+	return &accessToken{
+		value:     resp.AccessToken,
+		expiresAt: requestedAt.Add(time.Duration(resp.ExpiresInSec) * time.Second),
+	}, nil
+}
@@ -0,0 +1,105 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestForTaxpayerUsesIntermediaryToken(t *testing.T) {
+	var mu sync.Mutex
+	logins := make(map[string]int)
+	seenTokens := make(map[string]bool)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(INTERM_LOGIN_ENDPOINT, func(w http.ResponseWriter, r *http.Request) {
+		tin := r.Header.Get("onbehalfof")
+
+		mu.Lock()
+		logins[tin]++
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{"access_token":"token-%s","expires_in":3600,"token_type":"Bearer","scope":"InvoicingAPI"}`, tin)
+	})
+	mux.HandleFunc(GET_DOCUMENT_TYPES_ENDPOINT, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seenTokens[r.Header.Get("Authorization")] = true
+		mu.Unlock()
+
+		w.Write([]byte(`{"result":[]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		tin := "C1000000001"
+		if i%2 == 1 {
+			tin = "C2000000002"
+		}
+
+		wg.Add(1)
+		go func(tin string) {
+			defer wg.Done()
+
+			if _, err := api.ForTaxpayer(tin).GetDocumentTypes(context.Background()); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(tin)
+	}
+	wg.Wait()
+
+	for _, tin := range []string{"C1000000001", "C2000000002"} {
+		if logins[tin] != 1 {
+			t.Errorf("expected %d login for %s, got %d", 1, tin, logins[tin])
+		}
+
+		if !seenTokens["Bearer token-"+tin] {
+			t.Errorf("expected a request with the token of %s", tin)
+		}
+	}
+
+	if len(seenTokens) != 2 {
+		t.Errorf("expected %d distinct tokens, got %d", 2, len(seenTokens))
+	}
+}
+
+func TestForTaxpayerPoolsSessions(t *testing.T) {
+	api := NewApi("clientId", "clientSecret")
+
+	first := api.ForTaxpayer("C1000000001")
+	second := api.ForTaxpayer("C1000000001")
+	other := api.ForTaxpayer("C2000000002")
+
+	if first != second {
+		t.Errorf("expected the same session for the same TIN")
+	}
+
+	if first == other {
+		t.Errorf("expected different sessions for different TINs")
+	}
+
+	if first.ForTaxpayer("C2000000002") != other {
+		t.Errorf("expected sessions derived from a session to share the pool")
+	}
+
+	if first.OnBehalfOf() != "C1000000001" {
+		t.Errorf("expected OnBehalfOf to be %s, got %s", "C1000000001", first.OnBehalfOf())
+	}
+
+	if api.OnBehalfOf() != "" {
+		t.Errorf("expected OnBehalfOf of the root client to be empty, got %s", api.OnBehalfOf())
+	}
+
+	api.ForgetTaxpayer("C1000000001")
+
+	if api.ForTaxpayer("C1000000001") == first {
+		t.Errorf("expected a new session after ForgetTaxpayer")
+	}
+}
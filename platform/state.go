@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A document can only be cancelled or rejected this long after it was validated
+const STATE_CHANGE_WINDOW = 72 * time.Hour
+
+// Maximum length of the reason given to cancel or reject a document, in characters
+const MAX_STATE_CHANGE_REASON_LENGTH = 300
+
+var (
+	ErrReasonRequired   = errors.New("a reason is required")
+	ErrReasonTooLong    = fmt.Errorf("reason exceeds %d characters", MAX_STATE_CHANGE_REASON_LENGTH)
+	ErrDocumentNotValid = errors.New("document is not in the Valid state")
+	ErrWindowExpired    = errors.New("the 72 hour window has passed")
+)
+
+// StateChangeError is returned when a document is known not to be
+// cancellable or rejectable before calling the API. Use errors.Is with
+// the Err variables above to find out why.
+type StateChangeError struct {
+	UUID   string
+	Action string // cancel or reject
+	Err    error
+}
+
+func (e *StateChangeError) Error() string {
+	return fmt.Sprintf("cannot %s document %s: %s", e.Action, e.UUID, e.Err)
+}
+
+func (e *StateChangeError) Unwrap() error {
+	return e.Err
+}
+
+type stateChangeOptions struct {
+	precheck bool
+}
+
+type StateChangeOption func(*stateChangeOptions)
+
+// Fetch the document first and make sure it is Valid and still within the
+// 72 hour window, instead of finding out from the API.
+func WithPrecheck() StateChangeOption {
+	return func(o *stateChangeOptions) {
+		o.precheck = true
+	}
+}
+
+func validateReason(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+
+	if utf8.RuneCountInString(reason) > MAX_STATE_CHANGE_REASON_LENGTH {
+		return ErrReasonTooLong
+	}
+
+	return nil
+}
+
+// Check whether a document with the given details can still be cancelled or
+// rejected at now.
+func CheckStateChange(details *GetDocumentDetailsResponse, now time.Time) error {
+	if !strings.EqualFold(details.Status, "Valid") {
+		return fmt.Errorf("%w: %s", ErrDocumentNotValid, details.Status)
+	}
+
+	validatedAt, err := time.Parse(time.RFC3339Nano, details.DateTimeValidated)
+	if err != nil {
+		return fmt.Errorf("invalid dateTimeValidated %q: %w", details.DateTimeValidated, err)
+	}
+
+	if deadline := validatedAt.Add(STATE_CHANGE_WINDOW); now.After(deadline) {
+		return fmt.Errorf("%w: it ended at %s", ErrWindowExpired, deadline.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (a *Api) checkStateChange(ctx context.Context, docUuid string, reason string, action string, opts []StateChangeOption) error {
+	var o stateChangeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := validateReason(reason); err != nil {
+		return &StateChangeError{UUID: docUuid, Action: action, Err: err}
+	}
+
+	if !o.precheck {
+		return nil
+	}
+
+	details, err := a.GetDocumentDetails(ctx, docUuid)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckStateChange(details, time.Now()); err != nil {
+		return &StateChangeError{UUID: docUuid, Action: action, Err: err}
+	}
+
+	return nil
+}
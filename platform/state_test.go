@@ -0,0 +1,119 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStateTestServer serves the details of a document in the given status,
+// validated at validatedAt, and records every state change request
+func newStateTestServer(t *testing.T, status string, validatedAt time.Time, stateStatus int, requests *[]string) *Api {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/details") {
+			fmt.Fprintf(w, `{"uuid":"F9D425P6DS7D8IU","status":%q,"dateTimeValidated":%q}`, status, validatedAt.UTC().Format(time.RFC3339))
+			return
+		}
+
+		var body CancelDocumentRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		*requests = append(*requests, r.Method+" "+r.URL.Path+" "+body.DesiredStatus)
+
+		w.WriteHeader(stateStatus)
+		if stateStatus == http.StatusOK {
+			fmt.Fprintf(w, `{"uuid":"F9D425P6DS7D8IU","status":%q}`, body.DesiredStatus)
+		} else {
+			w.Write([]byte(`{"error":{"code":"OperationPeriodOver","message":"The time to cancel the document has passed"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+}
+
+func TestCancelDocumentWithPrecheck(t *testing.T) {
+	var requests []string
+	api := newStateTestServer(t, "Valid", time.Now().Add(-time.Hour), http.StatusOK, &requests)
+
+	resp, err := api.CancelDocument(context.Background(), "F9D425P6DS7D8IU", "Wrong buyer details", WithPrecheck())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resp.Status != "cancelled" {
+		t.Errorf("expected status to be %s, got %s", "cancelled", resp.Status)
+	}
+
+	expected := "PUT /api/v1.0/documents/state/F9D425P6DS7D8IU/state cancelled"
+	if len(requests) != 1 || requests[0] != expected {
+		t.Errorf("expected request %q, got %v", expected, requests)
+	}
+}
+
+func TestRejectDocument(t *testing.T) {
+	var requests []string
+	api := newStateTestServer(t, "Valid", time.Now(), http.StatusOK, &requests)
+
+	resp, err := api.RejectDocument(context.Background(), "F9D425P6DS7D8IU", "Wrong invoice details")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resp.Status != "rejected" {
+		t.Errorf("expected status to be %s, got %s", "rejected", resp.Status)
+	}
+
+	expected := "PUT /api/v1.0/documents/state/F9D425P6DS7D8IU/state rejected"
+	if len(requests) != 1 || requests[0] != expected {
+		t.Errorf("expected request %q, got %v", expected, requests)
+	}
+}
+
+func TestStateChangePrecheckFailures(t *testing.T) {
+	cases := []struct {
+		label       string
+		status      string
+		validatedAt time.Time
+		reason      string
+		expected    error
+	}{
+		{label: "expired", status: "Valid", validatedAt: time.Now().Add(-73 * time.Hour), reason: "Wrong buyer details", expected: ErrWindowExpired},
+		{label: "not valid", status: "Cancelled", validatedAt: time.Now(), reason: "Wrong buyer details", expected: ErrDocumentNotValid},
+		{label: "empty reason", status: "Valid", validatedAt: time.Now(), reason: " ", expected: ErrReasonRequired},
+		{label: "long reason", status: "Valid", validatedAt: time.Now(), reason: strings.Repeat("é", 301), expected: ErrReasonTooLong},
+	}
+
+	for _, test := range cases {
+		var requests []string
+		api := newStateTestServer(t, test.status, test.validatedAt, http.StatusOK, &requests)
+
+		_, err := api.CancelDocument(context.Background(), "F9D425P6DS7D8IU", test.reason, WithPrecheck())
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.label, test.expected, err)
+		}
+
+		var stateErr *StateChangeError
+		if !errors.As(err, &stateErr) || stateErr.Action != "cancel" {
+			t.Errorf("%s: expected StateChangeError, got %v", test.label, err)
+		}
+
+		if len(requests) != 0 {
+			t.Errorf("%s: expected no state change request, got %v", test.label, requests)
+		}
+	}
+}
+
+func TestCancelDocumentAPIError(t *testing.T) {
+	var requests []string
+	api := newStateTestServer(t, "Valid", time.Now(), http.StatusBadRequest, &requests)
+
+	_, err := api.CancelDocument(context.Background(), "F9D425P6DS7D8IU", "Wrong buyer details")
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type TINStatus int
+
+const (
+	TIN_UNKNOWN TINStatus = iota // could not be checked, e.g. network error, rate limiting or a malformed check, see TINResult.Err
+	TIN_VALID
+	TIN_INVALID
+)
+
+func (s TINStatus) String() string {
+	switch s {
+	case TIN_VALID:
+		return "valid"
+	case TIN_INVALID:
+		return "invalid"
+	}
+
+	return "unknown"
+}
+
+type TINCheck struct {
+	TIN     string
+	IdType  TinIdType
+	IdValue string
+}
+
+type TINResult struct {
+	TINCheck
+	Status TINStatus
+	Err    error // why the status is unknown, an *APIError with status 400 for a malformed check
+}
+
+type tinCacheEntry struct {
+	result    TINResult
+	expiresAt time.Time
+}
+
+// in-flight check shared by concurrent callers
+type tinCall struct {
+	done   chan struct{}
+	result TINResult
+}
+
+// TINValidator validates TINs with bounded concurrency, caching valid and
+// invalid results for a while. Unknown results, including those of
+// malformed checks, are never cached. The cache holds a bounded number of
+// results, so that bulk validation does not grow it without limit.
+//
+// It is safe for concurrent use.
+type TINValidator struct {
+	api         *Api
+	concurrency int
+	ttl         time.Duration
+	cacheSize   int
+	now         func() time.Time
+
+	mu       sync.Mutex
+	cache    map[TINCheck]tinCacheEntry
+	inFlight map[TINCheck]*tinCall
+}
+
+type TINValidatorOption func(*TINValidator)
+
+// Validate at most n TINs at a time in ValidateAll. Defaults to 4.
+func WithTINConcurrency(n int) TINValidatorOption {
+	return func(v *TINValidator) {
+		if n > 0 {
+			v.concurrency = n
+		}
+	}
+}
+
+// Cache results for ttl. Defaults to 24 hours. 0 disables caching.
+func WithTINCacheTTL(ttl time.Duration) TINValidatorOption {
+	return func(v *TINValidator) {
+		v.ttl = ttl
+	}
+}
+
+// Cache at most n results. Defaults to 10000. When the cache is full,
+// expired results are dropped first, then arbitrary ones.
+func WithTINCacheSize(n int) TINValidatorOption {
+	return func(v *TINValidator) {
+		if n > 0 {
+			v.cacheSize = n
+		}
+	}
+}
+
+func NewTINValidator(api *Api, opts ...TINValidatorOption) *TINValidator {
+	v := TINValidator{
+		api:         api,
+		concurrency: 4,
+		ttl:         24 * time.Hour,
+		cacheSize:   10000,
+		now:         time.Now,
+		cache:       make(map[TINCheck]tinCacheEntry),
+		inFlight:    make(map[TINCheck]*tinCall),
+	}
+
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	return &v
+}
+
+// Validate a single TIN, from the cache if possible. Concurrent calls for
+// the same check share a single request.
+func (v *TINValidator) Validate(ctx context.Context, check TINCheck) TINResult {
+	v.mu.Lock()
+
+	if entry, ok := v.cache[check]; ok {
+		if v.now().Before(entry.expiresAt) {
+			v.mu.Unlock()
+			return entry.result
+		}
+
+		delete(v.cache, check)
+	}
+
+	if call, ok := v.inFlight[check]; ok {
+		v.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.result
+		case <-ctx.Done():
+			return TINResult{TINCheck: check, Status: TIN_UNKNOWN, Err: ctx.Err()}
+		}
+	}
+
+	call := tinCall{done: make(chan struct{})}
+	v.inFlight[check] = &call
+	v.mu.Unlock()
+
+	call.result = v.validate(ctx, check)
+
+	v.mu.Lock()
+	delete(v.inFlight, check)
+	if call.result.Status != TIN_UNKNOWN && v.ttl > 0 {
+		v.store(check, call.result)
+	}
+	v.mu.Unlock()
+
+	close(call.done)
+
+	return call.result
+}
+
+// Cache the result, making room if the cache is full. Must be called with
+// v.mu held.
+func (v *TINValidator) store(check TINCheck, result TINResult) {
+	now := v.now()
+
+	if len(v.cache) >= v.cacheSize {
+		for key, entry := range v.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(v.cache, key)
+			}
+		}
+	}
+
+	for key := range v.cache {
+		if len(v.cache) < v.cacheSize {
+			break
+		}
+
+		delete(v.cache, key)
+	}
+
+	v.cache[check] = tinCacheEntry{result: result, expiresAt: now.Add(v.ttl)}
+}
+
+func (v *TINValidator) validate(ctx context.Context, check TINCheck) TINResult {
+	result := TINResult{TINCheck: check}
+
+	valid, err := v.api.ValidateTIN(ctx, check.TIN, check.IdType, check.IdValue)
+
+	switch {
+	case err == nil && valid:
+		result.Status = TIN_VALID
+	case err == nil:
+		// only a 404 means the TIN does not exist or does not match the ID
+		result.Status = TIN_INVALID
+	default:
+		// including a 400 for a malformed check, e.g. a bad idType, which
+		// says nothing about the TIN
+		result.Status = TIN_UNKNOWN
+		result.Err = err
+	}
+
+	return result
+}
+
+// ValidateAll validates every check, at most the configured number at a
+// time. Results are in the same order as checks.
+func (v *TINValidator) ValidateAll(ctx context.Context, checks []TINCheck) []TINResult {
+	results := make([]TINResult, len(checks))
+
+	sem := make(chan struct{}, v.concurrency)
+	var wg sync.WaitGroup
+
+	for i, check := range checks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = TINResult{TINCheck: check, Status: TIN_UNKNOWN, Err: ctx.Err()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, check TINCheck) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = v.Validate(ctx, check)
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,221 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTINTestApi answers TIN validation based on the TIN: VALID* exist,
+// DOWN* fail with 503, BAD* are malformed requests and anything else is not
+// found
+func newTINTestApi(t *testing.T, calls *int64, active *int64, maxActive *int64) *Api {
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+
+		if active != nil {
+			n := atomic.AddInt64(active, 1)
+			defer atomic.AddInt64(active, -1)
+
+			for {
+				max := atomic.LoadInt64(maxActive)
+				if n <= max || atomic.CompareAndSwapInt64(maxActive, max, n) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		tin := strings.TrimPrefix(r.URL.Path, "/api/v1.0/taxpayer/validate/")
+
+		switch {
+		case strings.HasPrefix(tin, "VALID"):
+			w.WriteHeader(http.StatusOK)
+		case strings.HasPrefix(tin, "DOWN"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasPrefix(tin, "BAD"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"errorCode":"BadArgument","error":"Invalid idType"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return NewApi("clientId", "clientSecret",
+		WithApiBaseUrl(server.URL),
+		WithIdentityBaseUrl(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithRateLimits(nil),
+	)
+}
+
+func TestValidateTINNotFound(t *testing.T) {
+	var calls int64
+	api := newTINTestApi(t, &calls, nil, nil)
+
+	valid, err := api.ValidateTIN(context.Background(), "C0000000000", ID_BRN, "201901234567")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if valid {
+		t.Errorf("expected TIN to be invalid")
+	}
+}
+
+func TestTINValidatorStatusesAndCache(t *testing.T) {
+	var calls int64
+	api := newTINTestApi(t, &calls, nil, nil)
+
+	validator := NewTINValidator(api)
+
+	checks := []TINCheck{
+		{TIN: "VALID1", IdType: ID_BRN, IdValue: "201901234567"},
+		{TIN: "C0000000000", IdType: ID_BRN, IdValue: "201901234567"},
+		{TIN: "DOWN1", IdType: ID_NRIC, IdValue: "770625015324"},
+		{TIN: "VALID1", IdType: ID_BRN, IdValue: "201901234567"},
+	}
+
+	results := validator.ValidateAll(context.Background(), checks)
+
+	expected := []TINStatus{TIN_VALID, TIN_INVALID, TIN_UNKNOWN, TIN_VALID}
+	for i, result := range results {
+		if result.Status != expected[i] {
+			t.Errorf("%s: expected status to be %s, got %s", result.TIN, expected[i], result.Status)
+		}
+	}
+
+	if results[2].Err == nil {
+		t.Errorf("expected an error for the unknown result")
+	}
+
+	before := atomic.LoadInt64(&calls)
+
+	// valid and invalid results are cached, unknown ones are not
+	validator.ValidateAll(context.Background(), checks)
+
+	if after := atomic.LoadInt64(&calls); after-before != 1 {
+		t.Errorf("expected 1 request for the unknown TIN only, got %d", after-before)
+	}
+}
+
+func TestTINValidatorMalformedCheck(t *testing.T) {
+	var calls int64
+	api := newTINTestApi(t, &calls, nil, nil)
+
+	validator := NewTINValidator(api)
+	check := TINCheck{TIN: "BAD1", IdType: "FOO", IdValue: "201901234567"}
+
+	result := validator.Validate(context.Background(), check)
+
+	if result.Status != TIN_UNKNOWN {
+		t.Errorf("expected status to be %s, got %s", TIN_UNKNOWN, result.Status)
+	}
+
+	var apiErr *APIError
+	if !errors.As(result.Err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected APIError with status 400, got %v", result.Err)
+	}
+
+	// not cached as invalid
+	validator.Validate(context.Background(), check)
+
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+}
+
+func TestTINValidatorCacheExpiry(t *testing.T) {
+	var calls int64
+	api := newTINTestApi(t, &calls, nil, nil)
+
+	now := time.Now()
+	validator := NewTINValidator(api, WithTINCacheTTL(time.Hour))
+	validator.now = func() time.Time { return now }
+
+	check := TINCheck{TIN: "VALID1", IdType: ID_BRN, IdValue: "201901234567"}
+
+	validator.Validate(context.Background(), check)
+	validator.Validate(context.Background(), check)
+
+	now = now.Add(2 * time.Hour)
+	validator.Validate(context.Background(), check)
+
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+}
+
+func TestTINValidatorCacheSize(t *testing.T) {
+	var calls int64
+	api := newTINTestApi(t, &calls, nil, nil)
+
+	now := time.Now()
+	validator := NewTINValidator(api, WithTINCacheTTL(time.Hour), WithTINCacheSize(2))
+	validator.now = func() time.Time { return now }
+
+	for _, tin := range []string{"VALID1", "VALID2", "VALID3"} {
+		validator.Validate(context.Background(), TINCheck{TIN: tin, IdType: ID_BRN, IdValue: "201901234567"})
+	}
+
+	if len(validator.cache) != 2 {
+		t.Errorf("expected 2 cached results, got %d", len(validator.cache))
+	}
+
+	latest := TINCheck{TIN: "VALID3", IdType: ID_BRN, IdValue: "201901234567"}
+	if _, ok := validator.cache[latest]; !ok {
+		t.Errorf("expected the latest result to be cached")
+	}
+
+	// expired results make room before unexpired ones are evicted
+	now = now.Add(2 * time.Hour)
+	validator.Validate(context.Background(), TINCheck{TIN: "VALID4", IdType: ID_BRN, IdValue: "201901234567"})
+
+	if len(validator.cache) != 1 {
+		t.Errorf("expected only the latest result to be cached, got %d", len(validator.cache))
+	}
+}
+
+func TestTINValidatorConcurrency(t *testing.T) {
+	var calls, active, maxActive int64
+	api := newTINTestApi(t, &calls, &active, &maxActive)
+
+	validator := NewTINValidator(api, WithTINConcurrency(3))
+
+	var checks []TINCheck
+	for i := 0; i < 12; i++ {
+		checks = append(checks, TINCheck{TIN: "VALID" + string(rune('A'+i)), IdType: ID_BRN, IdValue: "201901234567"})
+	}
+
+	// the same check from concurrent callers is only sent once
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			validator.Validate(context.Background(), TINCheck{TIN: "VALIDZ", IdType: ID_BRN, IdValue: "1"})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected concurrent identical checks to share 1 request, got %d", calls)
+	}
+
+	for _, result := range validator.ValidateAll(context.Background(), checks) {
+		if result.Status != TIN_VALID {
+			t.Errorf("%s: expected status to be valid, got %s", result.TIN, result.Status)
+		}
+	}
+
+	if maxActive > 3 {
+		t.Errorf("expected at most 3 concurrent requests, got %d", maxActive)
+	}
+}
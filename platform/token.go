@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Scope requested when logging in as a taxpayer system
+const DEFAULT_LOGIN_SCOPE = "InvoicingAPI"
+
+// Tokens are refreshed this long before they actually expire,
+// so that a request in flight does not race the expiry.
+const TOKEN_REFRESH_MARGIN = 60 * time.Second
+
+type accessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// tokenSource caches an access token and logs in again when it is about to
+// expire. It is safe for concurrent use; concurrent callers waiting on an
+// expired token share a single login.
+type tokenSource struct {
+	mu      sync.Mutex
+	login   func(ctx context.Context) (*accessToken, error)
+	current *accessToken
+	now     func() time.Time
+}
+
+func newTokenSource(login func(ctx context.Context) (*accessToken, error)) *tokenSource {
+	return &tokenSource{
+		login: login,
+		now:   time.Now,
+	}
+}
+
+// Token returns a valid access token, logging in if there is none yet or
+// the cached one is about to expire.
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.now().Add(TOKEN_REFRESH_MARGIN).Before(s.current.expiresAt) {
+		return s.current.value, nil
+	}
+
+	tok, err := s.login(ctx)
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+
+	s.current = tok
+
+	return tok.value, nil
+}
+
+// Invalidate drops the cached token if it is still the given one, forcing
+// the next call to Token to log in again.
+func (s *tokenSource) Invalidate(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.current.value == value {
+		s.current = nil
+	}
+}
+
+// log in with the client credentials the Api was created with
+func (a *Api) taxPayerToken(ctx context.Context) (*accessToken, error) {
+	requestedAt := time.Now()
+
+	resp, err := a.DoTaxPayerLogin(ctx, NewTaxPayerLoginRequest(a.clientId, a.clientSecret, []string{DEFAULT_LOGIN_SCOPE}))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in login response: %s %s", resp.Error, resp.ErrorDescription)
+	}
+
+	return &accessToken{
+		value:     resp.AccessToken,
+		expiresAt: requestedAt.Add(time.Duration(resp.ExpiresInSec) * time.Second),
+	}, nil
+}
+
+// do sends a request to endpoint with a bearer token attached and returns
+// the response along with its fully read body.
+//
+// If the platform responds with 401, the token is refreshed and the request
+// is retried once. Cancellation follows the context of the request.
+func (a *Api) do(endpoint string, req *http.Request) (*http.Response, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		tok, err := a.tokens.Token(req.Context())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+tok)
+
+		resp, respBytes, err := a.send(endpoint, req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 1 {
+			return resp, respBytes, nil
+		}
+
+		a.logger.Info("access token rejected, logging in again", "endpoint", endpoint)
+		a.tokens.Invalidate(tok)
+
+		if err := rewind(req); err != nil {
+			return nil, nil, err
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestServer serves the login endpoint with a fresh token on every call
+// and hands every other request to next.
+func newTestServer(next http.Handler) *httptest.Server {
+	var logins int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(TAXPAYER_LOGIN_ENDPOINT, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&logins, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"token_type":"Bearer","scope":"InvoicingAPI"}`, n)
+	})
+	mux.Handle("/", next)
+
+	return httptest.NewServer(mux)
+}
+
+func TestTokenSourceCachesToken(t *testing.T) {
+	logins := 0
+	source := newTokenSource(func(ctx context.Context) (*accessToken, error) {
+		logins++
+		return &accessToken{value: fmt.Sprintf("token-%d", logins), expiresAt: time.Now().Add(time.Hour)}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		tok, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if tok != "token-1" {
+			t.Errorf("expected token to be %s, got %s", "token-1", tok)
+		}
+	}
+
+	if logins != 1 {
+		t.Errorf("expected %d login, got %d", 1, logins)
+	}
+}
+
+func TestTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	logins := 0
+
+	source := newTokenSource(func(ctx context.Context) (*accessToken, error) {
+		logins++
+		return &accessToken{value: fmt.Sprintf("token-%d", logins), expiresAt: now.Add(time.Hour)}, nil
+	})
+	source.now = func() time.Time { return now }
+
+	if tok, _ := source.Token(context.Background()); tok != "token-1" {
+		t.Errorf("expected token to be %s, got %s", "token-1", tok)
+	}
+
+	// still outside the refresh margin
+	now = now.Add(time.Hour - TOKEN_REFRESH_MARGIN - time.Second)
+	if tok, _ := source.Token(context.Background()); tok != "token-1" {
+		t.Errorf("expected token to be %s, got %s", "token-1", tok)
+	}
+
+	// inside the refresh margin
+	now = now.Add(2 * time.Second)
+	if tok, _ := source.Token(context.Background()); tok != "token-2" {
+		t.Errorf("expected token to be %s, got %s", "token-2", tok)
+	}
+}
+
+func TestTokenSourceConcurrentLogin(t *testing.T) {
+	var logins int64
+
+	source := newTokenSource(func(ctx context.Context) (*accessToken, error) {
+		atomic.AddInt64(&logins, 1)
+		time.Sleep(10 * time.Millisecond)
+		return &accessToken{value: "token", expiresAt: time.Now().Add(time.Hour)}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := source.Token(context.Background()); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if logins != 1 {
+		t.Errorf("expected %d login, got %d", 1, logins)
+	}
+}
+
+func TestDoRetriesOnceOnUnauthorized(t *testing.T) {
+	var authHeaders []string
+
+	server := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer server.Close()
+
+	api := NewApi("clientId", "clientSecret", WithApiBaseUrl(server.URL), WithIdentityBaseUrl(server.URL))
+
+	if _, err := api.GetDocumentTypes(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"Bearer token-1", "Bearer token-2"}
+
+	if len(authHeaders) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(authHeaders))
+	}
+
+	for i := range expected {
+		if authHeaders[i] != expected[i] {
+			t.Errorf("expected request %d to carry %q, got %q", i, expected[i], authHeaders[i])
+		}
+	}
+}
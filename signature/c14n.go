@@ -0,0 +1,320 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Canonical XML 1.1 without comments, as used by the document reference.
+// https://www.w3.org/TR/xml-c14n11/
+//
+// Only the parts that UBL documents need are implemented: there is no DTD
+// processing and xml:* attributes are not inherited into document subsets.
+
+var errApexNotFound = errors.New("element not found")
+
+var (
+	nameUBLExtensions = xml.Name{Space: "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2", Local: "UBLExtensions"}
+	nameSignature     = xml.Name{Space: "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2", Local: "Signature"}
+)
+
+// The canonical document, without the signature elements removed by the
+// XPath transforms of the document reference.
+func canonicalDocument(data []byte) ([]byte, error) {
+	return canonicalize(data, xml.Name{}, nameUBLExtensions, nameSignature)
+}
+
+// The canonical form of the first element named apex and its descendants,
+// or of the whole document if apex is empty. Elements named in exclude are
+// left out together with their descendants.
+func canonicalize(data []byte, apex xml.Name, exclude ...xml.Name) ([]byte, error) {
+	c := canonicalizer{
+		d:       xml.NewDecoder(bytes.NewReader(data)),
+		apex:    apex,
+		exclude: exclude,
+	}
+
+	if err := c.run(); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize document: %w", err)
+	}
+
+	return c.buf.Bytes(), nil
+}
+
+type canonicalizer struct {
+	d       *xml.Decoder
+	apex    xml.Name
+	exclude []xml.Name
+	buf     bytes.Buffer
+
+	scopes   []map[string]string // namespaces in scope, one map per open element
+	rendered []map[string]string // namespaces rendered, one map per open output element
+	depth    int                 // open elements
+	output   int                 // depth of the apex, 0 if not inside it
+	skip     int                 // depth of an excluded element, 0 if not inside one
+	done     bool                // the apex has been closed
+	wrote    bool                // anything outside the document element has been written
+}
+
+func (c *canonicalizer) run() error {
+	for !c.done {
+		tok, err := c.d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := c.start(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			c.end(t)
+		case xml.CharData:
+			if c.writing() && c.depth > 0 {
+				c.buf.WriteString(escapeText(string(t)))
+			}
+		case xml.ProcInst:
+			if t.Target != "xml" && c.writing() {
+				c.procInst(t)
+			}
+		}
+	}
+
+	if c.apex.Local != "" && c.output == 0 && !c.done {
+		return fmt.Errorf("%w: %s", errApexNotFound, c.apex.Local)
+	}
+
+	return nil
+}
+
+// Whether tokens at the current position are part of the output.
+func (c *canonicalizer) writing() bool {
+	return c.skip == 0 && (c.apex.Local == "" || c.output > 0)
+}
+
+func (c *canonicalizer) start(t xml.StartElement) error {
+	c.depth++
+
+	scope := map[string]string{}
+	if len(c.scopes) > 0 {
+		for prefix, uri := range c.scopes[len(c.scopes)-1] {
+			scope[prefix] = uri
+		}
+	}
+
+	for _, attr := range t.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			scope[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			scope[""] = attr.Value
+		}
+	}
+
+	c.scopes = append(c.scopes, scope)
+
+	name, err := resolve(scope, t.Name, true)
+	if err != nil {
+		return err
+	}
+
+	if c.skip > 0 {
+		return nil
+	}
+
+	for _, excluded := range c.exclude {
+		if name == excluded {
+			c.skip = c.depth
+			return nil
+		}
+	}
+
+	if c.output == 0 && c.apex.Local != "" {
+		if name != c.apex {
+			return nil
+		}
+
+		c.output = c.depth
+	}
+
+	if c.depth == 1 && c.wrote {
+		c.buf.WriteByte('\n')
+	}
+
+	parent := map[string]string{}
+	if len(c.rendered) > 0 {
+		parent = c.rendered[len(c.rendered)-1]
+	}
+
+	rendered := map[string]string{}
+	for prefix, uri := range parent {
+		rendered[prefix] = uri
+	}
+
+	var prefixes []string
+	for prefix, uri := range scope {
+		if uri == "" && prefix != "" {
+			continue
+		}
+
+		if current, ok := parent[prefix]; ok && current == uri {
+			continue
+		}
+
+		// xmlns="" is only needed to undeclare a default namespace
+		if prefix == "" && uri == "" && parent[""] == "" {
+			continue
+		}
+
+		prefixes = append(prefixes, prefix)
+		rendered[prefix] = uri
+	}
+
+	c.rendered = append(c.rendered, rendered)
+
+	sort.Strings(prefixes)
+
+	// sorted by namespace URI and local name; the prefix plays no part
+	type attribute struct {
+		space string
+		local string
+		name  string // as written
+		value string
+	}
+
+	var attrs []attribute
+	for _, attr := range t.Attr {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+
+		resolved, err := resolve(scope, attr.Name, false)
+		if err != nil {
+			return err
+		}
+
+		attrs = append(attrs, attribute{space: resolved.Space, local: attr.Name.Local, name: qualified(attr.Name), value: attr.Value})
+	}
+
+	sort.SliceStable(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+
+		return attrs[i].local < attrs[j].local
+	})
+
+	c.buf.WriteByte('<')
+	c.buf.WriteString(qualified(t.Name))
+
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		c.buf.WriteString(escapeAttr(scope[prefix]))
+		c.buf.WriteByte('"')
+	}
+
+	for _, attr := range attrs {
+		c.buf.WriteString(" " + attr.name + `="`)
+		c.buf.WriteString(escapeAttr(attr.value))
+		c.buf.WriteByte('"')
+	}
+
+	c.buf.WriteByte('>')
+
+	return nil
+}
+
+func (c *canonicalizer) end(t xml.EndElement) {
+	if c.writing() {
+		c.buf.WriteString("</" + qualified(t.Name) + ">")
+		c.rendered = c.rendered[:len(c.rendered)-1]
+
+		if c.depth == 1 {
+			c.wrote = true
+		}
+	}
+
+	if c.skip == c.depth {
+		c.skip = 0
+	}
+
+	if c.output == c.depth {
+		c.done = true
+	}
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.depth--
+}
+
+func (c *canonicalizer) procInst(t xml.ProcInst) {
+	if c.depth == 0 && c.wrote {
+		c.buf.WriteByte('\n')
+	}
+
+	c.buf.WriteString("<?" + t.Target)
+	if len(t.Inst) > 0 {
+		c.buf.WriteString(" " + string(t.Inst))
+	}
+	c.buf.WriteString("?>")
+
+	if c.depth == 0 {
+		c.wrote = true
+	}
+}
+
+// The name with its prefix resolved to a namespace URI. Unprefixed
+// attributes are in no namespace.
+func resolve(scope map[string]string, name xml.Name, element bool) (xml.Name, error) {
+	if name.Space == "" {
+		if element {
+			return xml.Name{Space: scope[""], Local: name.Local}, nil
+		}
+
+		return name, nil
+	}
+
+	if name.Space == "xml" {
+		return xml.Name{Space: "http://www.w3.org/XML/1998/namespace", Local: name.Local}, nil
+	}
+
+	uri, ok := scope[name.Space]
+	if !ok || uri == "" {
+		return xml.Name{}, fmt.Errorf("undeclared namespace prefix %q", name.Space)
+	}
+
+	return xml.Name{Space: uri, Local: name.Local}, nil
+}
+
+func qualified(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
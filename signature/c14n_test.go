@@ -0,0 +1,96 @@
+package signature
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- leading comment -->
+<Invoice xmlns="urn:invoice" xmlns:cbc="urn:cbc" xmlns:cac="urn:cac" xmlns:ext="urn:ext">
+  <ext:UBLExtensions><ext:UBLExtension/></ext:UBLExtensions>
+  <cbc:ID b="2" a='1 &amp; "3"'/><!-- comment -->
+  <cac:Party xmlns:cbc="urn:cbc" xmlns:x="urn:x" x:z="z" y="y">
+    <cbc:Name>A &amp; B &lt; C &gt; D</cbc:Name>
+  </cac:Party>
+</Invoice>`
+
+	tests := []struct {
+		name     string
+		apex     xml.Name
+		exclude  []xml.Name
+		expected string
+	}{
+		{
+			"document",
+			xml.Name{},
+			nil,
+			`<Invoice xmlns="urn:invoice" xmlns:cac="urn:cac" xmlns:cbc="urn:cbc" xmlns:ext="urn:ext">
+  <ext:UBLExtensions><ext:UBLExtension></ext:UBLExtension></ext:UBLExtensions>
+  <cbc:ID a="1 &amp; &quot;3&quot;" b="2"></cbc:ID>
+  <cac:Party xmlns:x="urn:x" y="y" x:z="z">
+    <cbc:Name>A &amp; B &lt; C &gt; D</cbc:Name>
+  </cac:Party>
+</Invoice>`,
+		},
+		{
+			"excluded",
+			xml.Name{},
+			[]xml.Name{{Space: "urn:ext", Local: "UBLExtensions"}, {Space: "urn:cbc", Local: "ID"}},
+			`<Invoice xmlns="urn:invoice" xmlns:cac="urn:cac" xmlns:cbc="urn:cbc" xmlns:ext="urn:ext">
+  ` + "\n  " + `
+  <cac:Party xmlns:x="urn:x" y="y" x:z="z">
+    <cbc:Name>A &amp; B &lt; C &gt; D</cbc:Name>
+  </cac:Party>
+</Invoice>`,
+		},
+		{
+			"subset",
+			xml.Name{Space: "urn:cac", Local: "Party"},
+			nil,
+			`<cac:Party xmlns="urn:invoice" xmlns:cac="urn:cac" xmlns:cbc="urn:cbc" xmlns:ext="urn:ext" xmlns:x="urn:x" y="y" x:z="z">
+    <cbc:Name>A &amp; B &lt; C &gt; D</cbc:Name>
+  </cac:Party>`,
+		},
+	}
+
+	for _, test := range tests {
+		canonical, err := canonicalize([]byte(doc), test.apex, test.exclude...)
+		if err != nil {
+			t.Fatalf("unexpected error in %s: %s", test.name, err)
+		}
+
+		if string(canonical) != test.expected {
+			t.Errorf("expected %s to be\n%s\ngot\n%s", test.name, test.expected, canonical)
+		}
+	}
+}
+
+func TestCanonicalizeAttributeOrder(t *testing.T) {
+	// sorted by namespace URI, then local name, whatever the prefixes
+	doc := `<a xmlns:q="urn:x" xmlns:p="urn:x" xmlns:z="urn:a" p:b="2" q:a="1" c="3" z:d="4"/>`
+	expected := `<a xmlns:p="urn:x" xmlns:q="urn:x" xmlns:z="urn:a" c="3" z:d="4" q:a="1" p:b="2"></a>`
+
+	canonical, err := canonicalize([]byte(doc), xml.Name{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(canonical) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, canonical)
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	_, err := canonicalize([]byte(`<a xmlns:b="urn:b"><b:c/></a>`), xml.Name{Space: "urn:b", Local: "d"})
+	if !errors.Is(err, errApexNotFound) {
+		t.Errorf("expected errApexNotFound, got %v", err)
+	}
+
+	_, err = canonicalize([]byte(`<a><b:c/></a>`), xml.Name{})
+	if err == nil {
+		t.Errorf("expected error for undeclared prefix")
+	}
+}
@@ -0,0 +1,192 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Documents in JSON are signed like the XML ones, but the signature elements
+// are removed by deleting the UBLExtensions and Signature properties of the
+// document, and JSON is canonicalized by minifying it.
+// https://sdk.myinvois.hasil.gov.my/signature-creation-json/
+
+var errJSONPathNotFound = errors.New("not found")
+
+// The document with the signature properties removed from its first element,
+// minified. Properties keep their order.
+func canonicalJSONDocument(data []byte) ([]byte, error) {
+	root, err := jsonMembers(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, member := range root {
+		if member.key == "_D" || member.key == "_A" || member.key == "_B" {
+			continue
+		}
+
+		elements, err := jsonElements(member.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document %s: %w", member.key, err)
+		}
+
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("empty document %s", member.key)
+		}
+
+		doc, err := jsonMembers(elements[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid document %s: %w", member.key, err)
+		}
+
+		unsigned := doc[:0:0]
+		for _, m := range doc {
+			if m.key != "UBLExtensions" && m.key != "Signature" {
+				unsigned = append(unsigned, m)
+			}
+		}
+
+		elements[0] = encodeJSONMembers(unsigned)
+		root[i].value = encodeJSONElements(elements)
+
+		break
+	}
+
+	return minifyJSON(encodeJSONMembers(root))
+}
+
+func minifyJSON(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// The properties of a JSON object, in document order.
+func jsonMembers(data []byte) ([]jsonMember, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+
+	if tok, err := d.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, got %v", tok)
+	}
+
+	var members []jsonMember
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := d.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		members = append(members, jsonMember{key: tok.(string), value: value})
+	}
+
+	return members, nil
+}
+
+func jsonElements(data []byte) ([]json.RawMessage, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, err
+	}
+
+	return elements, nil
+}
+
+func encodeJSONMembers(members []jsonMember) json.RawMessage {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes()
+}
+
+func encodeJSONElements(elements []json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(element)
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes()
+}
+
+// The value at path, where strings select properties and ints select array
+// elements.
+func jsonPath(data json.RawMessage, path ...any) (json.RawMessage, error) {
+	for i, step := range path {
+		var found bool
+
+		switch step := step.(type) {
+		case string:
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal(data, &object); err != nil {
+				return nil, fmt.Errorf("%v: %w", path[:i+1], err)
+			}
+
+			data, found = object[step]
+		case int:
+			elements, err := jsonElements(data)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", path[:i+1], err)
+			}
+
+			if step < len(elements) {
+				data, found = elements[step], true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%v: %w", path[:i+1], errJSONPathNotFound)
+		}
+	}
+
+	return data, nil
+}
+
+// The string at path. Values are usually wrapped, e.g. [{"_":"value"}].
+func jsonString(data json.RawMessage, path ...any) (string, error) {
+	raw, err := jsonPath(data, path...)
+	if err != nil {
+		return "", err
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("%v: %w", path, err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,143 @@
+package signature
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+var (
+	ErrNoCertificate      = errors.New("no certificate found")
+	ErrNoPrivateKey       = errors.New("no private key found")
+	ErrUnsupportedKey     = errors.New("unsupported private key, only RSA keys are supported")
+	ErrKeyMismatch        = errors.New("private key does not match the certificate")
+	ErrCertificateExpired = errors.New("certificate is expired or not yet valid")
+)
+
+// A signing certificate, such as one issued to the taxpayer by a certificate
+// authority accredited by LHDN, and its private key, loaded from files. Key
+// is the Signer for keys that may be kept in process memory.
+type Key struct {
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate // intermediate certificates, if any
+	PrivateKey  *rsa.PrivateKey
+}
+
+// Load the certificate and private key from PEM. certPEM may also contain
+// the chain of the signing certificate. The key may be PKCS#1 or PKCS#8.
+func LoadPEM(certPEM []byte, keyPEM []byte) (*Key, error) {
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	for block, rest := pem.Decode(keyPEM); block != nil; block, rest = pem.Decode(rest) {
+		var privateKey any
+
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return nil, ErrUnsupportedKey
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+
+		return newKey(certs, privateKey)
+	}
+
+	return nil, ErrNoPrivateKey
+}
+
+// Load the certificate, its chain and the private key from a PKCS#12 (.p12
+// or .pfx) file. Only the legacy encryption algorithms are supported; files
+// created with OpenSSL 3 need the -legacy flag.
+func LoadPKCS12(data []byte, password string) (*Key, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12: %w", err)
+	}
+
+	var certPEM []byte
+	var keyBlock *pem.Block
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})...)
+		case "PRIVATE KEY":
+			keyBlock = block
+		}
+	}
+
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyBlock == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	// ToPEM labels every key "PRIVATE KEY", but encodes RSA keys as PKCS#1
+	privateKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	return newKey(certs, privateKey)
+}
+
+func parseCertificates(certPEM []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, ErrNoCertificate
+	}
+
+	return certs, nil
+}
+
+// The certificate matching the private key signs, the others are its chain.
+func newKey(certs []*x509.Certificate, privateKey any) (*Key, error) {
+	rsaKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	for i, cert := range certs {
+		certKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok || !certKey.Equal(&rsaKey.PublicKey) {
+			continue
+		}
+
+		chain := append(append([]*x509.Certificate(nil), certs[:i]...), certs[i+1:]...)
+
+		return &Key{Certificate: cert, Chain: chain, PrivateKey: rsaKey}, nil
+	}
+
+	return nil, ErrKeyMismatch
+}
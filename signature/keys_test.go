@@ -0,0 +1,111 @@
+package signature
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+)
+
+// Self-signed certificate and key, valid from notBefore for a year.
+func newTestKey(t *testing.T, notBefore time.Time) *Key {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(20240701),
+		Subject:      pkix.Name{CommonName: "Test Taxpayer", Organization: []string{"einvoice-go"}, Country: []string{"MY"}},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	return &Key{Certificate: cert, PrivateKey: privateKey}
+}
+
+func readTestData(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", name, err)
+	}
+
+	return b
+}
+
+func TestLoadPEM(t *testing.T) {
+	key, err := LoadPEM(readTestData(t, "cert.pem"), readTestData(t, "key.pem"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if key.Certificate.Subject.CommonName != "Test Taxpayer" {
+		t.Errorf("expected certificate of Test Taxpayer, got %s", key.Certificate.Subject)
+	}
+
+	// PKCS#1 keys are accepted too
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key.PrivateKey)})
+	if _, err := LoadPEM(readTestData(t, "cert.pem"), pkcs1); err != nil {
+		t.Errorf("unexpected error for PKCS#1 key: %s", err)
+	}
+
+	other := newTestKey(t, time.Now())
+	otherPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other.PrivateKey)})
+
+	if _, err := LoadPEM(readTestData(t, "cert.pem"), otherPEM); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected ErrKeyMismatch, got %v", err)
+	}
+
+	if _, err := LoadPEM(readTestData(t, "key.pem"), readTestData(t, "key.pem")); !errors.Is(err, ErrNoCertificate) {
+		t.Errorf("expected ErrNoCertificate, got %v", err)
+	}
+
+	if _, err := LoadPEM(readTestData(t, "cert.pem"), readTestData(t, "cert.pem")); !errors.Is(err, ErrNoPrivateKey) {
+		t.Errorf("expected ErrNoPrivateKey, got %v", err)
+	}
+}
+
+func TestLoadPKCS12(t *testing.T) {
+	key, err := LoadPKCS12(readTestData(t, "cert.p12"), "password")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pemKey, err := LoadPEM(readTestData(t, "cert.pem"), readTestData(t, "key.pem"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !key.Certificate.Equal(pemKey.Certificate) {
+		t.Errorf("expected the same certificate as cert.pem")
+	}
+
+	if !key.PrivateKey.Equal(pemKey.PrivateKey) {
+		t.Errorf("expected the same private key as key.pem")
+	}
+
+	if _, err := LoadPKCS12(readTestData(t, "cert.p12"), "wrong"); err == nil {
+		t.Errorf("expected error for wrong password")
+	}
+}
@@ -0,0 +1,194 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+const (
+	ALGORITHM_C14N11     = "http://www.w3.org/2006/12/xml-c14n11"
+	ALGORITHM_XPATH      = "http://www.w3.org/TR/1999/REC-xpath-19991116"
+	ALGORITHM_SHA256     = "http://www.w3.org/2001/04/xmlenc#sha256"
+	ALGORITHM_RSA_SHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+
+	REFERENCE_TYPE_SIGNED_PROPERTIES = "http://uri.etsi.org/01903/v1.3.2#SignedProperties"
+
+	// Layout of xades:SigningTime, always in UTC
+	SIGNING_TIME_FORMAT = "2006-01-02T15:04:05Z"
+)
+
+// XPath filters of the document reference, removing the signature from the
+// document before it is digested
+var (
+	xpathUBLExtensions = "not(//ancestor-or-self::ext:UBLExtensions)"
+	xpathSignature     = "not(//ancestor-or-self::cac:Signature)"
+)
+
+var nameSignedProperties = xml.Name{Space: ubl.SIGNATURE_NAMESPACE_XADES, Local: "SignedProperties"}
+
+// Sign the invoice with an enveloped XAdES signature, replacing any existing
+// signature. The signature is stored in inv.UBLExtensions and inv.Signature,
+// so the invoice must not be changed afterwards; marshal it as it is.
+//
+// Reference: https://sdk.myinvois.hasil.gov.my/signature-creation/
+func SignInvoice(inv *ubl.UBL_Invoice, signer Signer, signingTime time.Time) error {
+	chain := signer.CertificateChain()
+	if len(chain) == 0 {
+		return ErrNoCertificate
+	}
+
+	cert := chain[0]
+
+	if signingTime.Before(cert.NotBefore) || signingTime.After(cert.NotAfter) {
+		return ErrCertificateExpired
+	}
+
+	unsigned := *inv
+	unsigned.UBLExtensions = nil
+	unsigned.Signature = nil
+
+	data, err := xml.Marshal(&unsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice: %w", err)
+	}
+
+	doc, err := canonicalDocument(data)
+	if err != nil {
+		return err
+	}
+
+	// As the platform requires, the signature value is over the document
+	// itself rather than over ds:SignedInfo
+	docDigest := sha256.Sum256(doc)
+
+	signatureValue, err := signer.Sign(docDigest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign invoice: %w", err)
+	}
+
+	// A signer holding another key than the certificate's would otherwise
+	// only be noticed by the platform
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrUnsupportedKey
+	}
+
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, docDigest[:], signatureValue); err != nil {
+		return ErrKeyMismatch
+	}
+
+	certDigest := sha256.Sum256(cert.Raw)
+
+	var certificates []string
+	for _, c := range chain {
+		certificates = append(certificates, base64.StdEncoding.EncodeToString(c.Raw))
+	}
+
+	signature := ubl.DS_Signature{
+		DSEnv: ubl.SIGNATURE_NAMESPACE_DS,
+		ID:    ubl.SIGNATURE_ELEMENT_ID,
+		SignedInfo: ubl.DS_SignedInfo{
+			CanonicalizationMethod: ubl.DS_Algorithm{Algorithm: ALGORITHM_C14N11},
+			SignatureMethod:        ubl.DS_Algorithm{Algorithm: ALGORITHM_RSA_SHA256},
+			Reference: []ubl.DS_Reference{
+				{
+					ID:  ubl.SIGNATURE_DOC_REFERENCE_ID,
+					URI: "",
+					Transforms: &ubl.DS_Transforms{
+						Transform: []ubl.DS_Transform{
+							{Algorithm: ALGORITHM_XPATH, XPath: &xpathUBLExtensions},
+							{Algorithm: ALGORITHM_XPATH, XPath: &xpathSignature},
+							{Algorithm: ALGORITHM_C14N11},
+						},
+					},
+					DigestMethod: ubl.DS_Algorithm{Algorithm: ALGORITHM_SHA256},
+					DigestValue:  base64.StdEncoding.EncodeToString(docDigest[:]),
+				},
+				{
+					Type:         REFERENCE_TYPE_SIGNED_PROPERTIES,
+					URI:          "#" + ubl.SIGNATURE_SIGNED_PROPS_ID,
+					DigestMethod: ubl.DS_Algorithm{Algorithm: ALGORITHM_SHA256},
+					// filled in once the signed properties are in place
+				},
+			},
+		},
+		SignatureValue: base64.StdEncoding.EncodeToString(signatureValue),
+		KeyInfo: ubl.DS_KeyInfo{
+			X509Data: ubl.DS_X509Data{X509Certificate: certificates},
+		},
+		Object: ubl.DS_Object{
+			QualifyingProperties: ubl.XADES_QualifyingProperties{
+				XADESEnv: ubl.SIGNATURE_NAMESPACE_XADES,
+				Target:   ubl.SIGNATURE_ELEMENT_ID,
+				SignedProperties: ubl.XADES_SignedProperties{
+					ID: ubl.SIGNATURE_SIGNED_PROPS_ID,
+					SignedSignatureProperties: ubl.XADES_SignedSignatureProperties{
+						SigningTime: signingTime.UTC().Format(SIGNING_TIME_FORMAT),
+						SigningCertificate: ubl.XADES_SigningCertificate{
+							Cert: ubl.XADES_Cert{
+								CertDigest: ubl.XADES_CertDigest{
+									DigestMethod: ubl.DS_Algorithm{Algorithm: ALGORITHM_SHA256},
+									DigestValue:  base64.StdEncoding.EncodeToString(certDigest[:]),
+								},
+								IssuerSerial: ubl.XADES_IssuerSerial{
+									X509IssuerName:   cert.Issuer.String(),
+									X509SerialNumber: cert.SerialNumber.String(),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	inv.UBLExtensions = &ubl.EXT_UBLExtensions{
+		UBLExtension: []ubl.EXT_UBLExtension{
+			{
+				ExtensionURI: ubl.SIGNATURE_EXTENSION_URI,
+				ExtensionContent: ubl.EXT_ExtensionContent{
+					UBLDocumentSignatures: &ubl.SIG_UBLDocumentSignatures{
+						SIGEnv: ubl.SIGNATURE_NAMESPACE_SIG,
+						SACEnv: ubl.SIGNATURE_NAMESPACE_SAC,
+						SBCEnv: ubl.SIGNATURE_NAMESPACE_SBC,
+						SignatureInformation: ubl.SAC_SignatureInformation{
+							ID:                    ubl.SIGNATURE_INFORMATION_ID,
+							ReferencedSignatureID: ubl.SIGNATURE_ID,
+							Signature:             signature,
+						},
+					},
+				},
+			},
+		},
+	}
+	inv.Signature = &ubl.CAC_Signature{
+		ID:              ubl.SIGNATURE_ID,
+		SignatureMethod: ubl.SIGNATURE_METHOD,
+	}
+
+	// The signed properties are digested in place, with the namespaces
+	// inherited from the invoice
+	data, err = xml.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice: %w", err)
+	}
+
+	props, err := canonicalize(data, nameSignedProperties)
+	if err != nil {
+		return err
+	}
+
+	propsDigest := sha256.Sum256(props)
+
+	info := inv.DocumentSignature()
+	info.Signature.SignedInfo.Reference[1].DigestValue = base64.StdEncoding.EncodeToString(propsDigest[:])
+
+	return nil
+}
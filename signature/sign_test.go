@@ -0,0 +1,144 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+func newTestInvoice() *ubl.UBL_Invoice {
+	inv := ubl.NewInvoice()
+	inv.ID = "INV-001"
+	inv.IssueDate = "2024-07-23"
+	inv.InvoiceTypeCode = ubl.CBC_InvoiceTypeCode{Value: "01", ListVersionID: "1.1"}
+	inv.DocumentCurrencyCode = "MYR"
+
+	return inv
+}
+
+func TestSignInvoice(t *testing.T) {
+	signingTime := time.Date(2024, 7, 23, 23, 14, 54, 0, time.FixedZone("MYT", 8*60*60))
+	key := newTestKey(t, signingTime.AddDate(0, -1, 0))
+
+	inv := newTestInvoice()
+	if err := SignInvoice(inv, key, signingTime); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("failed to marshal invoice: %s", err)
+	}
+
+	parsed, err := ubl.ParseInvoice(data)
+	if err != nil {
+		t.Fatalf("failed to parse signed invoice: %s", err)
+	}
+
+	if parsed.Signature == nil || parsed.Signature.ID != ubl.SIGNATURE_ID {
+		t.Errorf("expected cac:Signature with ID %s, got %+v", ubl.SIGNATURE_ID, parsed.Signature)
+	}
+
+	info := parsed.DocumentSignature()
+	if info == nil {
+		t.Fatalf("expected signature in UBLExtensions")
+	}
+
+	signature := info.Signature
+	props := signature.Object.QualifyingProperties.SignedProperties.SignedSignatureProperties
+
+	if props.SigningTime != "2024-07-23T15:14:54Z" {
+		t.Errorf("expected signing time to be 2024-07-23T15:14:54Z, got %s", props.SigningTime)
+	}
+
+	if props.SigningCertificate.Cert.IssuerSerial.X509SerialNumber != "20240701" {
+		t.Errorf("expected serial number to be 20240701, got %s", props.SigningCertificate.Cert.IssuerSerial.X509SerialNumber)
+	}
+
+	certDigest := sha256.Sum256(key.Certificate.Raw)
+	if props.SigningCertificate.Cert.CertDigest.DigestValue != base64.StdEncoding.EncodeToString(certDigest[:]) {
+		t.Errorf("expected cert digest to match the certificate")
+	}
+
+	doc, err := canonicalDocument(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	docDigest := sha256.Sum256(doc)
+	if signature.SignedInfo.Reference[0].DigestValue != base64.StdEncoding.EncodeToString(docDigest[:]) {
+		t.Errorf("expected doc digest to match the signed document")
+	}
+
+	signedProps, err := canonicalize(data, nameSignedProperties)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	propsDigest := sha256.Sum256(signedProps)
+	if signature.SignedInfo.Reference[1].DigestValue != base64.StdEncoding.EncodeToString(propsDigest[:]) {
+		t.Errorf("expected props digest to match the signed properties")
+	}
+
+	signatureValue, err := base64.StdEncoding.DecodeString(signature.SignatureValue)
+	if err != nil {
+		t.Fatalf("failed to decode signature value: %s", err)
+	}
+
+	if err := rsa.VerifyPKCS1v15(&key.PrivateKey.PublicKey, crypto.SHA256, docDigest[:], signatureValue); err != nil {
+		t.Errorf("expected signature value to verify, got %s", err)
+	}
+
+	// signing again replaces the signature, rather than signing it
+	if err := SignInvoice(inv, key, signingTime); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.DocumentSignature().Signature.SignedInfo.Reference[0].DigestValue != signature.SignedInfo.Reference[0].DigestValue {
+		t.Errorf("expected doc digest not to change when signing again")
+	}
+}
+
+func TestSignInvoiceExpiredCertificate(t *testing.T) {
+	key := newTestKey(t, time.Now().AddDate(-2, 0, 0))
+
+	inv := newTestInvoice()
+	if err := SignInvoice(inv, key, time.Now()); !errors.Is(err, ErrCertificateExpired) {
+		t.Errorf("expected ErrCertificateExpired, got %v", err)
+	}
+
+	if inv.UBLExtensions != nil || inv.Signature != nil {
+		t.Errorf("expected invoice to be left unsigned")
+	}
+}
+
+func TestSignInvoiceNotEncodedAsJSON(t *testing.T) {
+	signingTime := time.Date(2024, 7, 23, 23, 14, 54, 0, time.FixedZone("MYT", 8*60*60))
+	key := newTestKey(t, signingTime.AddDate(0, -1, 0))
+
+	inv := newTestInvoice()
+	if err := SignInvoice(inv, key, signingTime); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the signature covers the XML form only, so the JSON form would not verify
+	if _, err := ubl.MarshalInvoiceJSON(inv); !errors.Is(err, ubl.ErrSignedInvoice) {
+		t.Errorf("expected ErrSignedInvoice, got %v", err)
+	}
+
+	data, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("failed to marshal invoice: %s", err)
+	}
+
+	if _, err := Verify(data); err != nil {
+		t.Errorf("expected the XML form to verify, got %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package signaturetest provides an in-process stand-in for an HSM, to test
+// signing without key files or hardware.
+//
+//	hsm := signaturetest.NewHSM()
+//	err := signature.SignInvoice(inv, hsm, time.Now())
+//
+// Like a real HSM, the private key cannot be read from it.
+package signaturetest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/signature"
+)
+
+var ErrInvalidDigest = errors.New("digest is not SHA-256")
+
+// HSM is a software signer that generates and keeps its own key. It is safe
+// for concurrent use.
+type HSM struct {
+	key   *rsa.PrivateKey
+	chain []*x509.Certificate
+
+	mu         sync.Mutex
+	failures   []error
+	signatures int
+}
+
+var _ signature.Signer = (*HSM)(nil)
+
+type hsmConfig struct {
+	subject   pkix.Name
+	notBefore time.Time
+	notAfter  time.Time
+	keyBits   int
+	issuer    *HSM
+}
+
+type Option func(*hsmConfig)
+
+// Subject of the certificate. Defaults to CN=Test Taxpayer, C=MY.
+func WithSubject(subject pkix.Name) Option {
+	return func(c *hsmConfig) {
+		c.subject = subject
+	}
+}
+
+// Validity of the certificate. Defaults to a year from a day ago.
+func WithValidity(notBefore time.Time, notAfter time.Time) Option {
+	return func(c *hsmConfig) {
+		c.notBefore = notBefore
+		c.notAfter = notAfter
+	}
+}
+
+// Size of the generated RSA key. Defaults to 2048 bits.
+func WithKeyBits(bits int) Option {
+	return func(c *hsmConfig) {
+		c.keyBits = bits
+	}
+}
+
+// Have the certificate issued by the certificate of issuer, rather than
+// self-signed. The chain of the issuer becomes part of the chain.
+func WithIssuer(issuer *HSM) Option {
+	return func(c *hsmConfig) {
+		c.issuer = issuer
+	}
+}
+
+// Generate a key and a certificate for it. Panics if they cannot be
+// generated, as the HSM is only meant for tests.
+func NewHSM(opts ...Option) *HSM {
+	now := time.Now()
+
+	c := hsmConfig{
+		subject:   pkix.Name{CommonName: "Test Taxpayer", Country: []string{"MY"}},
+		notBefore: now.AddDate(0, 0, -1),
+		notAfter:  now.AddDate(1, 0, -1),
+		keyBits:   2048,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, c.keyBits)
+	if err != nil {
+		panic(fmt.Sprintf("signaturetest: failed to generate key: %s", err))
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		panic(fmt.Sprintf("signaturetest: failed to generate serial number: %s", err))
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               c.subject,
+		NotBefore:             c.notBefore,
+		NotAfter:              c.notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	parent, signer := &template, key
+	var chain []*x509.Certificate
+
+	if c.issuer != nil {
+		chain = c.issuer.CertificateChain()
+		parent, signer = chain[0], c.issuer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, parent, &key.PublicKey, signer)
+	if err != nil {
+		panic(fmt.Sprintf("signaturetest: failed to create certificate: %s", err))
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(fmt.Sprintf("signaturetest: failed to parse certificate: %s", err))
+	}
+
+	return &HSM{key: key, chain: append([]*x509.Certificate{cert}, chain...)}
+}
+
+func (h *HSM) Sign(digest []byte) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.failures) > 0 {
+		err := h.failures[0]
+		h.failures = h.failures[1:]
+
+		return nil, err
+	}
+
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, ErrInvalidDigest
+	}
+
+	h.signatures++
+
+	return rsa.SignPKCS1v15(rand.Reader, h.key, crypto.SHA256, digest)
+}
+
+func (h *HSM) CertificateChain() []*x509.Certificate {
+	return append([]*x509.Certificate(nil), h.chain...)
+}
+
+// Fail the next call to Sign with err, e.g. to simulate an HSM that is
+// unavailable. Failures queue up when called repeatedly.
+func (h *HSM) FailNext(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures = append(h.failures, err)
+}
+
+// Number of digests signed so far.
+func (h *HSM) Signatures() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.signatures
+}
@@ -0,0 +1,98 @@
+package signaturetest_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/signature"
+	"github.com/programmer-my/einvoice-go/signature/signaturetest"
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+func signAndVerify(t *testing.T, signer signature.Signer) *signature.Verification {
+	t.Helper()
+
+	inv := ubl.NewInvoice()
+	inv.ID = "INV-001"
+	inv.IssueDate = "2024-07-23"
+
+	if err := signature.SignInvoice(inv, signer, time.Now()); err != nil {
+		t.Fatalf("failed to sign invoice: %s", err)
+	}
+
+	data, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("failed to marshal invoice: %s", err)
+	}
+
+	verification, err := signature.Verify(data)
+	if err != nil {
+		t.Fatalf("failed to verify invoice: %s", err)
+	}
+
+	return verification
+}
+
+func TestHSM(t *testing.T) {
+	hsm := signaturetest.NewHSM()
+
+	verification := signAndVerify(t, hsm)
+
+	if !verification.Certificate.Equal(hsm.CertificateChain()[0]) {
+		t.Errorf("expected the certificate of the HSM")
+	}
+
+	if hsm.Signatures() != 1 {
+		t.Errorf("expected 1 signature, got %d", hsm.Signatures())
+	}
+
+	if _, err := hsm.Sign([]byte("not a digest")); !errors.Is(err, signaturetest.ErrInvalidDigest) {
+		t.Errorf("expected ErrInvalidDigest, got %v", err)
+	}
+}
+
+func TestHSMChain(t *testing.T) {
+	ca := signaturetest.NewHSM()
+	hsm := signaturetest.NewHSM(signaturetest.WithIssuer(ca))
+
+	verification := signAndVerify(t, hsm)
+
+	if len(verification.Chain) != 1 || !verification.Chain[0].Equal(ca.CertificateChain()[0]) {
+		t.Fatalf("expected the certificate of the issuer in the chain, got %d certificates", len(verification.Chain))
+	}
+
+	if err := verification.Certificate.CheckSignatureFrom(verification.Chain[0]); err != nil {
+		t.Errorf("expected the certificate to be issued by the issuer, got %s", err)
+	}
+}
+
+func TestHSMFailNext(t *testing.T) {
+	hsm := signaturetest.NewHSM()
+	unavailable := errors.New("HSM unavailable")
+	hsm.FailNext(unavailable)
+
+	inv := ubl.NewInvoice()
+	if err := signature.SignInvoice(inv, hsm, time.Now()); !errors.Is(err, unavailable) {
+		t.Errorf("expected the HSM error, got %v", err)
+	}
+
+	if inv.UBLExtensions != nil {
+		t.Errorf("expected invoice to be left unsigned")
+	}
+
+	signAndVerify(t, hsm)
+}
+
+func TestHSMExpired(t *testing.T) {
+	hsm := signaturetest.NewHSM(signaturetest.WithValidity(time.Now().AddDate(-2, 0, 0), time.Now().AddDate(-1, 0, 0)))
+
+	if err := signature.SignInvoice(ubl.NewInvoice(), hsm, time.Now()); !errors.Is(err, signature.ErrCertificateExpired) {
+		t.Errorf("expected ErrCertificateExpired, got %v", err)
+	}
+
+	if hsm.Signatures() != 0 {
+		t.Errorf("expected no signatures, got %d", hsm.Signatures())
+	}
+}
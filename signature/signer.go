@@ -0,0 +1,54 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"os"
+)
+
+// Signer holds a signing key, wherever it is kept: in a file (Key), in an
+// HSM or in a key management service. The private key never has to leave
+// it, since only digests are sent to it.
+type Signer interface {
+	// Sign a SHA-256 digest with RSASSA-PKCS1-v1_5, the only algorithm the
+	// platform accepts.
+	Sign(digest []byte) ([]byte, error)
+	// The signing certificate first, followed by its chain, if any.
+	CertificateChain() []*x509.Certificate
+}
+
+func (k *Key) Sign(digest []byte) ([]byte, error) {
+	return rsa.SignPKCS1v15(rand.Reader, k.PrivateKey, crypto.SHA256, digest)
+}
+
+func (k *Key) CertificateChain() []*x509.Certificate {
+	return append([]*x509.Certificate{k.Certificate}, k.Chain...)
+}
+
+// Load the certificate and private key from PEM files, see LoadPEM. Both may
+// be the same file.
+func LoadPEMFile(certPath string, keyPath string) (*Key, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadPEM(certPEM, keyPEM)
+}
+
+// Load the certificate and private key from a PKCS#12 file, see LoadPKCS12.
+func LoadPKCS12File(path string, password string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadPKCS12(data, password)
+}
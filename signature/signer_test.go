@@ -0,0 +1,60 @@
+package signature
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+)
+
+// A signer whose key does not match its certificate.
+type mismatchedSigner struct {
+	key  *Key
+	cert *x509.Certificate
+}
+
+func (s *mismatchedSigner) Sign(digest []byte) ([]byte, error) {
+	return s.key.Sign(digest)
+}
+
+func (s *mismatchedSigner) CertificateChain() []*x509.Certificate {
+	return []*x509.Certificate{s.cert}
+}
+
+func TestSignInvoiceKeyMismatch(t *testing.T) {
+	key := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+	other := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+
+	inv := newTestInvoice()
+	err := SignInvoice(inv, &mismatchedSigner{key: key, cert: other.Certificate}, testSigningTime)
+	if !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected ErrKeyMismatch, got %v", err)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	pemKey, err := LoadPEMFile("testdata/cert.pem", "testdata/key.pem")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p12Key, err := LoadPKCS12File("testdata/cert.p12", "password")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !pemKey.Certificate.Equal(p12Key.Certificate) {
+		t.Errorf("expected the same certificate from both files")
+	}
+
+	for _, signer := range []Signer{pemKey, p12Key} {
+		inv := newTestInvoice()
+		if err := SignInvoice(inv, signer, time.Now()); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+
+	if _, err := LoadPEMFile("testdata/missing.pem", "testdata/key.pem"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
@@ -0,0 +1,343 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+// Checks made by Verify, in order
+const (
+	CHECK_SIGNED          = "Signed"
+	CHECK_SIGNING_TIME    = "SigningTime"
+	CHECK_CERTIFICATE     = "Certificate"
+	CHECK_CERT_DIGEST     = "CertDigest"
+	CHECK_DOC_DIGEST      = "DocDigest"
+	CHECK_PROPS_DIGEST    = "PropsDigest"
+	CHECK_SIGNATURE_VALUE = "SignatureValue"
+)
+
+var (
+	ErrNotSigned          = errors.New("document is not signed")
+	ErrSigningTimeFormat  = errors.New("signing time is not in the format " + SIGNING_TIME_FORMAT)
+	ErrDigestMismatch     = errors.New("digest does not match")
+	ErrSignatureMismatch  = errors.New("signature value does not match")
+	ErrUnsupportedFormat  = errors.New("document is neither XML nor JSON")
+	ErrInvalidCertificate = errors.New("invalid certificate")
+)
+
+// VerificationError reports the first check of a signature that failed.
+// Err is one of the errors above, possibly wrapped, so that errors.Is can be
+// used on a VerificationError.
+type VerificationError struct {
+	Check string // one of the CHECK_* constants
+	Err   error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("%s check failed: %s", e.Check, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// The signer of a verified document.
+type Verification struct {
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate // other certificates embedded in the signature
+	SigningTime time.Time
+}
+
+// The parts of a signed document that Verify checks, whichever its format.
+type signedDocument struct {
+	doc            []byte // canonical document, without its signature
+	props          []byte // canonical signed properties
+	docDigest      string
+	propsDigest    string
+	certDigest     string
+	signatureValue string
+	certificates   []string
+	signingTime    string
+}
+
+// Verify the signature of a raw UBL document in XML or JSON, such as one
+// fetched with GetDocument. The digests are recomputed and the signature
+// value is checked against the certificate embedded in the document, which
+// must have been valid at the signing time. Whether the certificate is
+// trusted is not checked; verify Verification.Certificate against the
+// roots of your choice.
+//
+// A *VerificationError is returned if a check fails.
+func Verify(data []byte) (*Verification, error) {
+	var signed *signedDocument
+	var err error
+
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		signed, err = parseSignedXML(data)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		signed, err = parseSignedJSON(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return signed.verify()
+}
+
+func (s *signedDocument) verify() (*Verification, error) {
+	signingTime, err := time.Parse(SIGNING_TIME_FORMAT, s.signingTime)
+	if err != nil {
+		return nil, &VerificationError{CHECK_SIGNING_TIME, fmt.Errorf("%w: %q", ErrSigningTimeFormat, s.signingTime)}
+	}
+
+	var certs []*x509.Certificate
+	for _, encoded := range s.certificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, &VerificationError{CHECK_CERTIFICATE, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)}
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, &VerificationError{CHECK_CERTIFICATE, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)}
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, &VerificationError{CHECK_CERTIFICATE, fmt.Errorf("%w: %s", ErrInvalidCertificate, ErrNoCertificate)}
+	}
+
+	cert := certs[0]
+
+	if err := compareDigest(cert.Raw, s.certDigest); err != nil {
+		return nil, &VerificationError{CHECK_CERT_DIGEST, err}
+	}
+
+	if signingTime.Before(cert.NotBefore) || signingTime.After(cert.NotAfter) {
+		return nil, &VerificationError{CHECK_CERTIFICATE, fmt.Errorf("%w: valid from %s to %s, signed at %s",
+			ErrCertificateExpired, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339), s.signingTime)}
+	}
+
+	if err := compareDigest(s.doc, s.docDigest); err != nil {
+		return nil, &VerificationError{CHECK_DOC_DIGEST, err}
+	}
+
+	if err := compareDigest(s.props, s.propsDigest); err != nil {
+		return nil, &VerificationError{CHECK_PROPS_DIGEST, err}
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, &VerificationError{CHECK_CERTIFICATE, fmt.Errorf("%w: %s", ErrInvalidCertificate, ErrUnsupportedKey)}
+	}
+
+	signatureValue, err := base64.StdEncoding.DecodeString(s.signatureValue)
+	if err != nil {
+		return nil, &VerificationError{CHECK_SIGNATURE_VALUE, fmt.Errorf("%w: %s", ErrSignatureMismatch, err)}
+	}
+
+	docDigest := sha256.Sum256(s.doc)
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, docDigest[:], signatureValue); err != nil {
+		return nil, &VerificationError{CHECK_SIGNATURE_VALUE, ErrSignatureMismatch}
+	}
+
+	return &Verification{Certificate: cert, Chain: certs[1:], SigningTime: signingTime}, nil
+}
+
+func compareDigest(data []byte, expected string) error {
+	digest := sha256.Sum256(data)
+	actual := base64.StdEncoding.EncodeToString(digest[:])
+
+	if actual != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, expected, actual)
+	}
+
+	return nil
+}
+
+func parseSignedXML(data []byte) (*signedDocument, error) {
+	info, err := ubl.ParseSignature(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if info == nil {
+		return nil, &VerificationError{CHECK_SIGNED, ErrNotSigned}
+	}
+
+	signature := info.Signature
+	props := signature.Object.QualifyingProperties.SignedProperties.SignedSignatureProperties
+
+	signed := signedDocument{
+		certDigest:     props.SigningCertificate.Cert.CertDigest.DigestValue,
+		signatureValue: signature.SignatureValue,
+		certificates:   signature.KeyInfo.X509Data.X509Certificate,
+		signingTime:    props.SigningTime,
+	}
+
+	for _, ref := range signature.SignedInfo.Reference {
+		switch ref.URI {
+		case "":
+			signed.docDigest = ref.DigestValue
+		case "#" + signature.Object.QualifyingProperties.SignedProperties.ID:
+			signed.propsDigest = ref.DigestValue
+		}
+	}
+
+	if signed.doc, err = canonicalDocument(data); err != nil {
+		return nil, err
+	}
+
+	if signed.props, err = canonicalize(data, nameSignedProperties); err != nil {
+		return nil, err
+	}
+
+	return &signed, nil
+}
+
+// The signed properties are digested as the minified SignedProperties
+// object, as it appears in the document.
+func parseSignedJSON(data []byte) (*signedDocument, error) {
+	root, err := jsonMembers(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	var doc json.RawMessage
+	for _, member := range root {
+		if member.key != "_D" && member.key != "_A" && member.key != "_B" {
+			doc, err = jsonPath(member.value, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse document: %w", err)
+			}
+
+			break
+		}
+	}
+
+	if doc == nil {
+		return nil, fmt.Errorf("failed to parse document: %w", errJSONPathNotFound)
+	}
+
+	extensions, err := jsonPath(doc, "UBLExtensions", 0, "UBLExtension")
+	if errors.Is(err, errJSONPathNotFound) {
+		return nil, &VerificationError{CHECK_SIGNED, ErrNotSigned}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	elements, err := jsonElements(extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	var signature json.RawMessage
+	for _, ext := range elements {
+		if uri, _ := jsonString(ext, "ExtensionURI", 0, "_"); uri != ubl.SIGNATURE_EXTENSION_URI {
+			continue
+		}
+
+		signature, err = jsonPath(ext, "ExtensionContent", 0, "UBLDocumentSignatures", 0, "SignatureInformation", 0, "Signature", 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signature: %w", err)
+		}
+	}
+
+	if signature == nil {
+		return nil, &VerificationError{CHECK_SIGNED, ErrNotSigned}
+	}
+
+	var signed signedDocument
+
+	signedProps, err := jsonPath(signature, "Object", 0, "QualifyingProperties", 0, "SignedProperties", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	if signed.props, err = minifyJSON(signedProps); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	propsID, _ := jsonString(signedProps, "Id")
+
+	for _, field := range []struct {
+		dst  *string
+		data json.RawMessage
+		path []any
+	}{
+		{&signed.signingTime, signedProps, []any{"SignedSignatureProperties", 0, "SigningTime", 0, "_"}},
+		{&signed.certDigest, signedProps, []any{"SignedSignatureProperties", 0, "SigningCertificate", 0, "Cert", 0, "CertDigest", 0, "DigestValue", 0, "_"}},
+		{&signed.signatureValue, signature, []any{"SignatureValue", 0, "_"}},
+	} {
+		if *field.dst, err = jsonString(field.data, field.path...); err != nil {
+			return nil, fmt.Errorf("failed to parse signature: %w", err)
+		}
+	}
+
+	certificates, err := jsonPath(signature, "KeyInfo", 0, "X509Data", 0, "X509Certificate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	elements, err = jsonElements(certificates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	for _, cert := range elements {
+		encoded, err := jsonString(cert, "_")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signature: %w", err)
+		}
+
+		signed.certificates = append(signed.certificates, encoded)
+	}
+
+	references, err := jsonPath(signature, "SignedInfo", 0, "Reference")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	elements, err = jsonElements(references)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	for _, ref := range elements {
+		uri, _ := jsonString(ref, "URI")
+
+		digest, err := jsonString(ref, "DigestValue", 0, "_")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signature: %w", err)
+		}
+
+		switch uri {
+		case "":
+			signed.docDigest = digest
+		case "#" + propsID:
+			signed.propsDigest = digest
+		}
+	}
+
+	if signed.doc, err = canonicalJSONDocument(data); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	return &signed, nil
+}
@@ -0,0 +1,254 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var testSigningTime = time.Date(2024, 7, 23, 15, 14, 54, 0, time.UTC)
+
+func newSignedTestInvoice(t *testing.T, key *Key) []byte {
+	t.Helper()
+
+	inv := newTestInvoice()
+	if err := SignInvoice(inv, key, testSigningTime); err != nil {
+		t.Fatalf("failed to sign invoice: %s", err)
+	}
+
+	data, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("failed to marshal invoice: %s", err)
+	}
+
+	return data
+}
+
+// A JSON invoice signed the same way as XML ones.
+func newSignedTestJSONInvoice(t *testing.T, key *Key, signingTime string) []byte {
+	t.Helper()
+
+	const namespaces = `"_D":"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",` +
+		`"_A":"urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",` +
+		`"_B":"urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"`
+	const fields = `"ID":[{"_":"INV-001"}],"IssueDate":[{"_":"2024-07-23"}]`
+
+	doc, err := canonicalJSONDocument([]byte(`{` + namespaces + `,"Invoice":[{` + fields + `}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	docDigest := sha256.Sum256(doc)
+	signatureValue, err := rsa.SignPKCS1v15(rand.Reader, key.PrivateKey, crypto.SHA256, docDigest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	certDigest := sha256.Sum256(key.Certificate.Raw)
+	props := fmt.Sprintf(`{"Id":"id-xades-signed-props","SignedSignatureProperties":[{"SigningTime":[{"_":%q}],`+
+		`"SigningCertificate":[{"Cert":[{"CertDigest":[{"DigestMethod":[{"_":"","Algorithm":%q}],"DigestValue":[{"_":%q}]}],`+
+		`"IssuerSerial":[{"X509IssuerName":[{"_":%q}],"X509SerialNumber":[{"_":%q}]}]}]}]}]}`,
+		signingTime, ALGORITHM_SHA256, base64.StdEncoding.EncodeToString(certDigest[:]),
+		key.Certificate.Issuer.String(), key.Certificate.SerialNumber.String())
+	propsDigest := sha256.Sum256([]byte(props))
+
+	signature := fmt.Sprintf(`{"Id":"signature",`+
+		`"SignedInfo":[{"SignatureMethod":[{"_":"","Algorithm":%q}],"Reference":[`+
+		`{"Type":%q,"URI":"#id-xades-signed-props","DigestMethod":[{"_":"","Algorithm":%q}],"DigestValue":[{"_":%q}]},`+
+		`{"Type":"","URI":"","DigestMethod":[{"_":"","Algorithm":%q}],"DigestValue":[{"_":%q}]}]}],`+
+		`"SignatureValue":[{"_":%q}],`+
+		`"KeyInfo":[{"X509Data":[{"X509Certificate":[{"_":%q}]}]}],`+
+		`"Object":[{"QualifyingProperties":[{"Target":"signature","SignedProperties":[%s]}]}]}`,
+		ALGORITHM_RSA_SHA256,
+		REFERENCE_TYPE_SIGNED_PROPERTIES, ALGORITHM_SHA256, base64.StdEncoding.EncodeToString(propsDigest[:]),
+		ALGORITHM_SHA256, base64.StdEncoding.EncodeToString(docDigest[:]),
+		base64.StdEncoding.EncodeToString(signatureValue),
+		base64.StdEncoding.EncodeToString(key.Certificate.Raw),
+		props)
+
+	extensions := `"UBLExtensions":[{"UBLExtension":[{"ExtensionURI":[{"_":"urn:oasis:names:specification:ubl:dsig:enveloped:xades"}],` +
+		`"ExtensionContent":[{"UBLDocumentSignatures":[{"SignatureInformation":[{"ID":[{"_":"urn:oasis:names:specification:ubl:signature:1"}],` +
+		`"ReferencedSignatureID":[{"_":"urn:oasis:names:specification:ubl:signature:Invoice"}],"Signature":[` + signature + `]}]}]}]}]}]`
+	reference := `"Signature":[{"ID":[{"_":"urn:oasis:names:specification:ubl:signature:Invoice"}],` +
+		`"SignatureMethod":[{"_":"urn:oasis:names:specification:ubl:dsig:enveloped:xades"}]}]`
+
+	// whitespace is not part of the canonical form
+	return []byte("{\n  " + namespaces + ",\n  \"Invoice\": [{" + extensions + ",\n    " + fields + ",\n    " + reference + "}]\n}")
+}
+
+func expectVerificationError(t *testing.T, name string, err error, check string, target error) {
+	t.Helper()
+
+	var verr *VerificationError
+	if !errors.As(err, &verr) {
+		t.Errorf("expected %s to fail with a VerificationError, got %v", name, err)
+		return
+	}
+
+	if verr.Check != check {
+		t.Errorf("expected %s to fail the %s check, got %s", name, check, verr.Check)
+	}
+
+	if !errors.Is(err, target) {
+		t.Errorf("expected %s to fail with %s, got %s", name, target, verr.Err)
+	}
+}
+
+func TestVerifyXML(t *testing.T) {
+	key := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+	data := newSignedTestInvoice(t, key)
+
+	verification, err := Verify(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !verification.Certificate.Equal(key.Certificate) {
+		t.Errorf("expected the signing certificate")
+	}
+
+	if !verification.SigningTime.Equal(testSigningTime) {
+		t.Errorf("expected signing time to be %s, got %s", testSigningTime, verification.SigningTime)
+	}
+
+	signingTime := []byte("2024-07-23T15:14:54Z")
+	signatureValue := []byte("<ds:SignatureValue>")
+
+	tests := []struct {
+		name   string
+		data   []byte
+		check  string
+		target error
+	}{
+		{"tampered document", bytes.Replace(data, []byte("INV-001"), []byte("INV-002"), 1), CHECK_DOC_DIGEST, ErrDigestMismatch},
+		{"tampered signing time", bytes.Replace(data, signingTime, []byte("2024-07-23T15:14:55Z"), 1), CHECK_PROPS_DIGEST, ErrDigestMismatch},
+		{"signing time with offset", bytes.Replace(data, signingTime, []byte("2024-07-23T15:14:54+00:00"), 1), CHECK_SIGNING_TIME, ErrSigningTimeFormat},
+		{"signing time after expiry", bytes.Replace(data, signingTime, []byte("2025-07-23T15:14:54Z"), 1), CHECK_CERTIFICATE, ErrCertificateExpired},
+		{"tampered signature value", bytes.Replace(data, signatureValue, append(signatureValue, "AAAA"...), 1), CHECK_SIGNATURE_VALUE, ErrSignatureMismatch},
+		{"unsigned", []byte(`<Invoice><cbc:ID xmlns:cbc="urn:cbc">INV-001</cbc:ID></Invoice>`), CHECK_SIGNED, ErrNotSigned},
+	}
+
+	for _, test := range tests {
+		_, err := Verify(test.data)
+		expectVerificationError(t, test.name, err, test.check, test.target)
+	}
+}
+
+func TestVerifyXMLOtherSigner(t *testing.T) {
+	key := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+	other := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+
+	data := newSignedTestInvoice(t, key)
+
+	// replace the embedded certificate and its digest, but not the signature
+	encode := func(b []byte) []byte {
+		return []byte(base64.StdEncoding.EncodeToString(b))
+	}
+	digest := func(b []byte) []byte {
+		d := sha256.Sum256(b)
+		return encode(d[:])
+	}
+
+	data = bytes.Replace(data, encode(key.Certificate.Raw), encode(other.Certificate.Raw), 1)
+	data = bytes.Replace(data, digest(key.Certificate.Raw), digest(other.Certificate.Raw), 1)
+
+	_, err := Verify(data)
+	expectVerificationError(t, "other signer", err, CHECK_PROPS_DIGEST, ErrDigestMismatch)
+}
+
+func TestVerifyJSON(t *testing.T) {
+	key := newTestKey(t, testSigningTime.AddDate(0, -1, 0))
+	data := newSignedTestJSONInvoice(t, key, "2024-07-23T15:14:54Z")
+
+	verification, err := Verify(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !verification.SigningTime.Equal(testSigningTime) {
+		t.Errorf("expected signing time to be %s, got %s", testSigningTime, verification.SigningTime)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		check  string
+		target error
+	}{
+		{"tampered document", bytes.Replace(data, []byte("INV-001"), []byte("INV-002"), 1), CHECK_DOC_DIGEST, ErrDigestMismatch},
+		{"tampered signing time", bytes.Replace(data, []byte("2024-07-23T15:14:54Z"), []byte("2024-07-23T15:14:55Z"), 1), CHECK_PROPS_DIGEST, ErrDigestMismatch},
+		{"signing time with offset", newSignedTestJSONInvoice(t, key, "2024-07-23T23:14:54+08:00"), CHECK_SIGNING_TIME, ErrSigningTimeFormat},
+		{"signing time after expiry", newSignedTestJSONInvoice(t, key, "2026-07-23T15:14:54Z"), CHECK_CERTIFICATE, ErrCertificateExpired},
+		{"unsigned", []byte(`{"_D":"urn:invoice","Invoice":[{"ID":[{"_":"INV-001"}]}]}`), CHECK_SIGNED, ErrNotSigned},
+	}
+
+	for _, test := range tests {
+		_, err := Verify(test.data)
+		expectVerificationError(t, test.name, err, test.check, test.target)
+	}
+}
+
+// testdata/invoice-signed.json is signed with testdata/key.pem by a separate
+// script following the platform JSON signature steps, laid out like the
+// JSON sample of the platform documentation.
+func TestVerifyJSONFixture(t *testing.T) {
+	data := readTestData(t, "invoice-signed.json")
+
+	verification, err := Verify(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC); !verification.SigningTime.Equal(expected) {
+		t.Errorf("expected signing time to be %s, got %s", expected, verification.SigningTime)
+	}
+
+	if verification.Certificate.Subject.CommonName != "Test Taxpayer" {
+		t.Errorf("expected the certificate of Test Taxpayer, got %s", verification.Certificate.Subject)
+	}
+
+	// whitespace is not part of the canonical form
+	if _, err := Verify(bytes.ReplaceAll(data, []byte("\n"), []byte("\n\t"))); err != nil {
+		t.Errorf("expected reindented document to verify, got %s", err)
+	}
+
+	signatureValue := []byte(`"SignatureValue": [{"_": "`)
+	i := bytes.Index(data, signatureValue) + len(signatureValue)
+	tamperedSignature := append([]byte(nil), data...)
+	tamperedSignature[i] ^= 'A' ^ 'B'
+
+	tests := []struct {
+		name   string
+		data   []byte
+		check  string
+		target error
+	}{
+		{"tampered invoice number", bytes.Replace(data, []byte("JSON-INV12345"), []byte("JSON-INV12346"), 1), CHECK_DOC_DIGEST, ErrDigestMismatch},
+		{"tampered amount", bytes.Replace(data, []byte(`"_": 1548.13`), []byte(`"_": 1548.14`), 1), CHECK_DOC_DIGEST, ErrDigestMismatch},
+		{"tampered signing time", bytes.Replace(data, []byte("2026-10-19T08:30:00Z"), []byte("2026-10-19T08:30:01Z"), 1), CHECK_PROPS_DIGEST, ErrDigestMismatch},
+		{"tampered signature value", tamperedSignature, CHECK_SIGNATURE_VALUE, ErrSignatureMismatch},
+	}
+
+	for _, test := range tests {
+		if bytes.Equal(test.data, data) {
+			t.Fatalf("expected %s to change the document", test.name)
+		}
+
+		_, err := Verify(test.data)
+		expectVerificationError(t, test.name, err, test.check, test.target)
+	}
+}
+
+func TestVerifyUnsupportedFormat(t *testing.T) {
+	if _, err := Verify([]byte("INV-001")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -20,7 +20,7 @@ import (
 
 func CurrencyMarshaler(m *money.Money, e *xml.Encoder, s xml.StartElement) error {
 	toEncode := struct {
-		Amount     string `xml:",innerxml"`
+		Amount     string `xml:",chardata"`
 		CurrencyID string `xml:"currencyID,attr"`
 	}{
 		Amount:     fmt.Sprintf("%.2f", m.AsMajorUnits()),
@@ -55,7 +55,7 @@ type UBL_Invoice struct {
 	UBLEnv  string   `xml:"xmlns:ubl,attr"`
 	/** XML Namespace Setup End **/
 
-	Signature                   string                           `xml:"cbc:Signature"`
+	UBLExtensions               *EXT_UBLExtensions               `xml:"ext:UBLExtensions,omitempty"`               // [0..1] 	Document signature, see DocumentSignature
 	CustomizationID             string                           `xml:"cbc:CustomizationID"`                       // [1..1] 	Specification identifier
 	ProfileID                   string                           `xml:"cbc:ProfileID"`                             // [1..1] 	Business process type
 	ID                          string                           `xml:"cbc:ID"`                                    // [1..1] 	Invoice number
@@ -63,12 +63,12 @@ type UBL_Invoice struct {
 	IssueDate                   string                           `xml:"cbc:IssueDate"`                             // [1..1] 	Invoice issue date
 	IssueTime                   *string                          `xml:"cbc:IssueTime,omitempty"`                   // [0..1] 	Invoice issue time
 	DueDate                     *string                          `xml:"cbc:DueDate,omitempty"`                     // [0..1] 	Payment due date
-	InvoiceTypeCode             string                           `xml:"cbc:InvoiceTypeCode"`                       // [1..1] 	Invoice type code
+	InvoiceTypeCode             CBC_InvoiceTypeCode              `xml:"cbc:InvoiceTypeCode"`                       // [1..1] 	Invoice type code
 	Note                        *string                          `xml:"cbc:Note,omitempty"`                        // [0..1] 	Invoice note
 	TaxPointDate                string                           `xml:"cbc:TaxPointDate"`                          // [0..0] 	TAX point date ???? 0..0?
 	Currency                    money.Currency                   `xml:"-"`                                         // for copying around in invoice line, legal monetary values, etc. not for serialization
 	DocumentCurrencyCode        string                           `xml:"cbc:DocumentCurrencyCode"`                  // [1..1] 	Invoice currency code
-	TaxCurrencyCode             *money.Currency                  `xml:"cbc:TaxCurrencyCode,omitempty"`             // [0..1] 	Tax accounting currency
+	TaxCurrencyCode             *CBC_TaxCurrencyCode             `xml:"cbc:TaxCurrencyCode,omitempty"`             // [0..1] 	Tax accounting currency
 	AccountingCost              *string                          `xml:"cbc:AccountingCost,omitempty"`              // [0..1] 	Buyer accounting reference
 	BuyerReference              *string                          `xml:"cbc:BuyerReference,omitempty"`              // [0..1] 	Buyer reference
 	InvoicePeriod               *CAC_InvoicePeriod               `xml:"cac:InvoicePeriod,omitempty"`               // [0..1] 	INVOICING PERIOD
@@ -80,6 +80,7 @@ type UBL_Invoice struct {
 	ContractDocumentReference   *CAC_ContractDocumentReference   `xml:"cac:ContractDocumentReference,omitempty"`   // [0..1] 	Contract reference
 	AdditionalDocumentReference *CAC_AdditionalDocumentReference `xml:"cac:AdditionalDocumentReference,omitempty"` // [0..1] [cbc:DocumentTypeCode = "938"] [cbc:DocumentTypeCode = "914"] [cbc:DocumentTypeCode = 130], [0..n] if [cbc:DocumentTypeCode != 130]
 	ProjectReference            *CAC_ProjectReference            `xml:"cac:ProjectReference,omitempty"`            // [0..1] 	Project reference
+	Signature                   *CAC_Signature                   `xml:"cac:Signature,omitempty"`                   // [0..1] 	Signature reference, required with UBLExtensions
 	AccountingSupplierParty     CAC_AccountingSupplierParty      `xml:"cac:AccountingSupplierParty"`               // [1..1] 	SELLER
 	AccountingCustomerParty     CAC_AccountingCustomerParty      `xml:"cac:AccountingCustomerParty"`               // [1..1] 	BUYER
 	PayeeParty                  *CAC_PayeeParty                  `xml:"cac:PayeeParty,omitempty"`                  // [0..1] 	Payee
@@ -96,11 +97,41 @@ type UBL_Invoice struct {
 }
 
 type CBC_DocumentCurrencyCode struct {
-	Code money.Currency
+	Code money.Currency `xml:",chardata"`
 }
 
-func (d *CBC_DocumentCurrencyCode) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
-	return e.EncodeToken(d.Code.Code)
+func (d CBC_DocumentCurrencyCode) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+	return e.EncodeElement(d.Code.Code, s)
+}
+
+func (d *CBC_DocumentCurrencyCode) UnmarshalXML(dec *xml.Decoder, s xml.StartElement) error {
+	currency, err := CurrencyCodeUnmarshaler(dec, s)
+	if err != nil {
+		return err
+	}
+
+	d.Code = *currency
+
+	return nil
+}
+
+type CBC_TaxCurrencyCode struct {
+	Code money.Currency `xml:",chardata"`
+}
+
+func (t CBC_TaxCurrencyCode) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+	return e.EncodeElement(t.Code.Code, s)
+}
+
+func (t *CBC_TaxCurrencyCode) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	currency, err := CurrencyCodeUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	t.Code = *currency
+
+	return nil
 }
 
 func (i *UBL_Invoice) AddItem() *CAC_InvoiceLine {
@@ -211,8 +242,14 @@ type CAC_Party struct {
 // 	Name string `xml:"name,attr"` // [1..1]
 // }
 
+// https://sdk.myinvois.hasil.gov.my/codes/e-invoice-types/
+type CBC_InvoiceTypeCode struct {
+	Value         string `xml:",chardata"`                    // [1..1] e.g. 01
+	ListVersionID string `xml:"listVersionID,attr,omitempty"` // [1..1] document type version, e.g. 1.1
+}
+
 type CBC_EndpointID struct {
-	Value    string `xml:",innerxml"`     // [1..1]
+	Value    string `xml:",chardata"`     // [1..1]
 	SchemeID string `xml:"schemeID,attr"` // [1..1]
 }
 
@@ -230,7 +267,7 @@ type CAC_PartyIdentification struct {
 // link tells us the above schemeID is accepted
 // but schematron says otherways (schemeID "0230" for malaysia e-invoice)
 type CAC_PartyIdentification_ID struct {
-	Value    string `xml:",innerxml"`
+	Value    string `xml:",chardata"`
 	SchemeID string `xml:"schemeID,attr"`
 }
 
@@ -409,16 +446,28 @@ type CAC_AllowanceCharge struct {
 }
 
 type CBC_Amount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_Amount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_Amount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_Amount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 // Valid values for ID: T E O (aligned-ibrp-cl-01-my)
 
 type CAC_TaxCategory struct {
@@ -447,15 +496,27 @@ type CAC_TaxTotal struct {
 }
 
 type CBC_TaxAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_TaxAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_TaxAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_TaxAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CAC_TaxSubtotal struct {
 	XMLName       xml.Name          `xml:"cac:TaxSubtotal"`
 	TaxableAmount CBC_TaxableAmount `xml:"cbc:TaxableAmount"` // [1..1] TAX category taxable amount. - Sum of all taxable amounts subject to a specific TAX category code and TAX category rate (if the TAX category rate is applicable).
@@ -464,15 +525,27 @@ type CAC_TaxSubtotal struct {
 }
 
 type CBC_TaxableAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_TaxableAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_TaxableAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_TaxableAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CAC_LegalMonetaryTotal struct {
 	XMLName               xml.Name                   `xml:"cac:LegalMonetaryTotal"`
 	LineExtensionAmount   CBC_LineExtensionAmount    `xml:"cbc:LineExtensionAmount"`   // [1..1] Sum of Invoice line net amount - Sum of all Invoice line net amounts in the Invoice.
@@ -486,85 +559,181 @@ type CAC_LegalMonetaryTotal struct {
 }
 
 type CBC_LineExtensionAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_LineExtensionAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_LineExtensionAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_LineExtensionAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_TaxExclusiveAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_TaxExclusiveAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_TaxExclusiveAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_TaxExclusiveAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_TaxInclusiveAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_TaxInclusiveAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_TaxInclusiveAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_TaxInclusiveAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_AllowanceTotalAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_AllowanceTotalAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_AllowanceTotalAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_AllowanceTotalAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_ChargeTotalAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_ChargeTotalAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_ChargeTotalAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_ChargeTotalAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_PrepaidAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_PrepaidAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_PrepaidAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_PrepaidAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_PayableRoundingAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_PayableRoundingAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_PayableRoundingAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_PayableRoundingAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 type CBC_PayableAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyID money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_PayableAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_PayableAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyID.Code)
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_PayableAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyID = *m.Currency()
+
+	return nil
+}
+
 // TODO: conflicting spec
 // Invoice Line Item section in https://sdk.myinvois.hasil.gov.my/documents/invoice-v1-1/#invoice-line-item
 // mentions "cac:ItemPriceExtension" (ubl:Invoice / cac:InvoiceLine / cac:ItemPriceExtension / cbc:Amount [@currencyID=’MYR’])
@@ -594,7 +763,7 @@ type CAC_InvoiceLine struct {
 // https://docs.peppol.eu/poac/my/pint-my/trn-invoice/syntax/cac-InvoiceLine/cbc-InvoicedQuantity/unitCode/
 // https://docs.peppol.eu/poac/my/pint-my/trn-invoice/codelist/UNECERec20/
 type CBC_InvoicedQuantity struct {
-	Value    string `xml:",innerxml"`
+	Value    string `xml:",chardata"`
 	UnitCode string `xml:"unitCode,attr"`
 }
 
@@ -684,16 +853,28 @@ type CAC_Price struct {
 }
 
 type CBC_PriceAmount struct {
-	Value      money.Amount   `xml:",innerxml"`       // required
+	Value      money.Amount   `xml:",chardata"`       // required
 	CurrencyId money.Currency `xml:"currencyID,attr"` // required
 }
 
-func (a *CBC_PriceAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
+func (a CBC_PriceAmount) MarshalXML(e *xml.Encoder, s xml.StartElement) error {
 	m := money.New(a.Value, a.CurrencyId.Code)
 
 	return CurrencyMarshaler(m, e, s)
 }
 
+func (a *CBC_PriceAmount) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	m, err := CurrencyUnmarshaler(d, s)
+	if err != nil {
+		return err
+	}
+
+	a.Value = m.Amount()
+	a.CurrencyId = *m.Currency()
+
+	return nil
+}
+
 // type Option[T any] func(*T)
 
 // func NewCAC_Price(priceAmount string, options ...Option[CAC_Price]) *CAC_Price {
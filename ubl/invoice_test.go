@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/Rhymond/go-money"
 	"github.com/go-playground/validator"
 	"github.com/programmer-my/einvoice-go/ubl"
 )
 
+var myr = money.GetCurrency(money.MYR)
+
 func Test_Marshal_UBL_Invoice(t *testing.T) {
 
 }
@@ -87,10 +90,8 @@ func Test_Marshal_CAC_Address(t *testing.T) {
 
 func Test_Marshal_CAC_PostalAddress(t *testing.T) {
 	postalAddr := ubl.CAC_PostalAddress{
-		CAC_Address: ubl.CAC_Address{
-			Country: ubl.CAC_Country{
-				IdentificationCode: "MY",
-			},
+		Country: ubl.CAC_Country{
+			IdentificationCode: "MY",
 		},
 	}
 
@@ -204,11 +205,11 @@ func Test_Marshal_CAC_TaxExchangeRate(t *testing.T) {
 
 func Test_Marshal_CAC_TaxTotal(t *testing.T) {
 	taxTotal := ubl.CAC_TaxTotal{
-		TaxAmount: "1.00",
+		TaxAmount: ubl.CBC_TaxAmount{Value: 100, CurrencyID: *myr},
 		TaxSubtotal: []ubl.CAC_TaxSubtotal{
 			{
-				TaxableAmount: "1.00",
-				TaxAmount:     "1.00",
+				TaxableAmount: ubl.CBC_TaxableAmount{Value: 100, CurrencyID: *myr},
+				TaxAmount:     ubl.CBC_TaxAmount{Value: 100, CurrencyID: *myr},
 				TaxCategory: ubl.CAC_TaxCategory{
 					ID:      "T",
 					Percent: "100",
@@ -301,7 +302,7 @@ func Test_Marshal_CAC_LotIdentification(t *testing.T) {
 
 func Test_Marshal_CAC_Price(t *testing.T) {
 	price := ubl.CAC_Price{
-		PriceAmount: "1.00",
+		PriceAmount: ubl.CBC_PriceAmount{Value: 100, CurrencyId: *myr},
 		// BaseQuantity: 2,
 		// AllowanceCharge: &ubl.CAC_AllowanceCharge{
 		// 	ChargeIndicator: true,
@@ -0,0 +1,636 @@
+package ubl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
+
+// UBL 2.1 JSON syntax, the alternative to XML accepted by the platform.
+// https://docs.oasis-open.org/ubl/UBL-2.1-JSON/v2.0/UBL-2.1-JSON-v2.0.html
+// https://sdk.myinvois.hasil.gov.my/documents/invoice-v1-1/
+//
+// Every element becomes a property named after the element without its
+// prefix, holding an array with an object per occurrence. Basic elements
+// keep their value in "_" and aggregates nest their children; attributes
+// are properties of the same object:
+//
+//	"TaxAmount": [{"_": 87.63, "currencyID": "MYR"}]
+//
+// The namespaces of the document, aggregate and basic components are
+// declared once, in "_D", "_A" and "_B".
+
+// Namespace URIs of the prefixes used in the struct tags of this package.
+var namespaces = map[string]string{
+	"cac":   "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+	"cbc":   "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+	"ext":   "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2",
+	"qdt":   "urn:oasis:names:specification:ubl:schema:xsd:QualifiedDataTypes-2",
+	"udt":   "urn:oasis:names:specification:ubl:schema:xsd:UnqualifiedDataTypes-2",
+	"cn":    "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2",
+	"ubl":   "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+	"sig":   SIGNATURE_NAMESPACE_SIG,
+	"sac":   SIGNATURE_NAMESPACE_SAC,
+	"sbc":   SIGNATURE_NAMESPACE_SBC,
+	"ds":    SIGNATURE_NAMESPACE_DS,
+	"xades": SIGNATURE_NAMESPACE_XADES,
+}
+
+// Basic elements whose values are numbers in JSON, by the suffix of their
+// names. Amounts are always numbers.
+var numericSuffixes = []string{"Quantity", "Percent", "Rate", "Numeric"}
+
+var (
+	typeAmount   = reflect.TypeOf(money.Amount(0))
+	typeCurrency = reflect.TypeOf(money.Currency{})
+	typeXMLName  = reflect.TypeOf(xml.Name{})
+)
+
+// Signatures made by signature.SignInvoice cover the XML form of the
+// invoice, so a signed invoice encoded as JSON would fail verification.
+var ErrSignedInvoice = errors.New("signed invoices can only be encoded as XML")
+
+// MarshalInvoiceJSON encodes the invoice in the UBL JSON syntax, e.g. for
+// submission as platform.FORMAT_JSON. Signed invoices are refused with
+// ErrSignedInvoice; submit them as XML.
+func MarshalInvoiceJSON(inv *UBL_Invoice) ([]byte, error) {
+	if inv.UBLExtensions != nil || inv.Signature != nil {
+		return nil, ErrSignedInvoice
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"_D":`)
+	writeJSONString(&buf, inv.UBLEnv)
+	buf.WriteString(`,"_A":`)
+	writeJSONString(&buf, inv.CACEnv)
+	buf.WriteString(`,"_B":`)
+	writeJSONString(&buf, inv.CBCEnv)
+	buf.WriteString(`,"Invoice":[`)
+
+	if err := encodeJSONElement(&buf, reflect.ValueOf(inv).Elem(), false); err != nil {
+		return nil, fmt.Errorf("failed to marshal invoice: %w", err)
+	}
+
+	buf.WriteString("]}")
+
+	return buf.Bytes(), nil
+}
+
+// ParseInvoiceJSON parses an invoice in the UBL JSON syntax, such as the raw
+// document returned by the platform for documents submitted as JSON.
+func ParseInvoiceJSON(data []byte) (*UBL_Invoice, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var doc map[string]any
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse invoice: %w", err)
+	}
+
+	inv := NewInvoice()
+
+	element, err := firstJSONElement(doc, "Invoice")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse invoice: %w", err)
+	}
+
+	if element == nil {
+		return nil, fmt.Errorf("failed to parse invoice: no Invoice in document")
+	}
+
+	if err := decodeJSONElement(element, reflect.ValueOf(inv).Elem(), "Invoice"); err != nil {
+		return nil, fmt.Errorf("failed to parse invoice: %w", err)
+	}
+
+	if inv.DocumentCurrencyCode != "" {
+		currency := money.GetCurrency(inv.DocumentCurrencyCode)
+		if currency == nil {
+			return nil, fmt.Errorf("failed to parse invoice: Invoice.DocumentCurrencyCode: unknown currency %q", inv.DocumentCurrencyCode)
+		}
+
+		inv.Currency = *currency
+	}
+
+	return inv, nil
+}
+
+// The parsed xml tag of a struct field.
+type xmlField struct {
+	index     int
+	name      string // without prefix
+	prefix    string
+	attr      bool
+	chardata  bool // chardata or innerxml
+	omitempty bool
+}
+
+func xmlFields(t reflect.Type) []xmlField {
+	var fields []xmlField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Type == typeXMLName {
+			continue
+		}
+
+		tag := f.Tag.Get("xml")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		field := xmlField{index: i, name: parts[0]}
+		if field.name == "" {
+			field.name = f.Name
+		}
+
+		if prefix, name, ok := strings.Cut(field.name, ":"); ok {
+			field.prefix, field.name = prefix, name
+		}
+
+		for _, flag := range parts[1:] {
+			switch flag {
+			case "attr":
+				field.attr = true
+			case "chardata", "innerxml":
+				field.chardata = true
+			case "omitempty":
+				field.omitempty = true
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Whether the struct is a basic element, with a value rather than children.
+func isBasic(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == typeCurrency {
+		return true
+	}
+
+	for _, f := range xmlFields(t) {
+		if !f.attr && !f.chardata {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNumeric(name string) bool {
+	for _, suffix := range numericSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+// Encode the object of a single occurrence of an element.
+func encodeJSONElement(buf *bytes.Buffer, v reflect.Value, numeric bool) error {
+	t := v.Type()
+
+	if t.Kind() != reflect.Struct || t == typeCurrency {
+		buf.WriteString(`{"_":`)
+		if err := encodeJSONValue(buf, v, numeric); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+
+		return nil
+	}
+
+	fields := xmlFields(t)
+	basic := isBasic(t)
+
+	// the currency of an amount, to format it
+	var currency *money.Currency
+	for _, f := range fields {
+		if f.attr && v.Field(f.index).Type() == typeCurrency {
+			c := v.Field(f.index).Interface().(money.Currency)
+			currency = &c
+		}
+	}
+
+	buf.WriteByte('{')
+	first := true
+
+	separate := func() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+	}
+
+	if basic {
+		separate()
+		buf.WriteString(`"_":`)
+
+		value := ""
+		for _, f := range fields {
+			if !f.chardata {
+				continue
+			}
+
+			fv := v.Field(f.index)
+			if fv.Type() == typeAmount {
+				if currency == nil {
+					return fmt.Errorf("amount without currency in %s", t.Name())
+				}
+
+				// the fraction of the code, whatever the struct was built with
+				fraction := 0
+				if c := money.GetCurrency(currency.Code); c != nil {
+					fraction = c.Fraction
+				} else if fv.Int() != 0 {
+					return fmt.Errorf("unknown currency %q in %s", currency.Code, t.Name())
+				}
+
+				value = formatMinorUnits(fv.Int(), fraction)
+				numeric = true
+			} else if fv.Type() == typeCurrency {
+				value = fv.Interface().(money.Currency).Code
+			} else {
+				value = fmt.Sprint(fv.Interface())
+			}
+		}
+
+		if err := encodeJSONValue(buf, reflect.ValueOf(value), numeric); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		fv := v.Field(f.index)
+
+		switch {
+		case f.chardata:
+			continue
+		case f.attr:
+			if f.prefix == "xmlns" || (f.omitempty && fv.IsZero()) {
+				continue
+			}
+
+			separate()
+			writeJSONString(buf, f.name)
+			buf.WriteByte(':')
+
+			if fv.Type() == typeCurrency {
+				writeJSONString(buf, fv.Interface().(money.Currency).Code)
+			} else {
+				writeJSONString(buf, fmt.Sprint(fv.Interface()))
+			}
+		default:
+			var occurrences []reflect.Value
+
+			switch fv.Kind() {
+			case reflect.Pointer:
+				if !fv.IsNil() {
+					occurrences = append(occurrences, fv.Elem())
+				}
+			case reflect.Slice:
+				for i := 0; i < fv.Len(); i++ {
+					occurrences = append(occurrences, fv.Index(i))
+				}
+			default:
+				if !(f.omitempty && fv.IsZero()) {
+					occurrences = append(occurrences, fv)
+				}
+			}
+
+			if len(occurrences) == 0 {
+				continue
+			}
+
+			separate()
+			writeJSONString(buf, f.name)
+			buf.WriteString(":[")
+
+			for i, occurrence := range occurrences {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+
+				for occurrence.Kind() == reflect.Pointer {
+					occurrence = occurrence.Elem()
+				}
+
+				if err := encodeJSONElement(buf, occurrence, isNumeric(f.name)); err != nil {
+					return err
+				}
+			}
+
+			buf.WriteByte(']')
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// Format an amount in minor units with fraction decimal places, exactly
+// rather than through a float.
+func formatMinorUnits(minor int64, fraction int) string {
+	digits := strconv.FormatInt(minor, 10)
+
+	sign := ""
+	if minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	if fraction <= 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= fraction {
+		digits = strings.Repeat("0", fraction-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-fraction] + "." + digits[len(digits)-fraction:]
+}
+
+// Encode the value of a basic element. Numeric values that are not numbers,
+// such as empty ones, are kept as strings.
+func encodeJSONValue(buf *bytes.Buffer, v reflect.Value, numeric bool) error {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if numeric {
+			if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+				buf.WriteString(s)
+				return nil
+			}
+		}
+
+		writeJSONString(buf, s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Struct:
+		if v.Type() != typeCurrency {
+			return fmt.Errorf("unsupported value of type %s", v.Type())
+		}
+
+		writeJSONString(buf, v.Interface().(money.Currency).Code)
+	default:
+		return fmt.Errorf("unsupported value of type %s", v.Type())
+	}
+
+	return nil
+}
+
+// The first occurrence of the element in the object, nil if there is none.
+func firstJSONElement(object map[string]any, name string) (map[string]any, error) {
+	elements, err := jsonElements(object, name)
+	if err != nil || len(elements) == 0 {
+		return nil, err
+	}
+
+	return elements[0], nil
+}
+
+func jsonElements(object map[string]any, name string) ([]map[string]any, error) {
+	value, ok := object[name]
+	if !ok {
+		return nil, nil
+	}
+
+	array, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", name)
+	}
+
+	elements := make([]map[string]any, len(array))
+	for i, element := range array {
+		if elements[i], ok = element.(map[string]any); !ok {
+			return nil, fmt.Errorf("%s[%d] is not an object", name, i)
+		}
+	}
+
+	return elements, nil
+}
+
+// Decode a single occurrence of an element into v.
+func decodeJSONElement(object map[string]any, v reflect.Value, path string) error {
+	t := v.Type()
+
+	if t.Kind() != reflect.Struct || t == typeCurrency {
+		return decodeJSONValue(object["_"], v, path)
+	}
+
+	fields := xmlFields(t)
+
+	// attributes first, so that amounts know their currency
+	var currency *money.Currency
+
+	for _, f := range fields {
+		if !f.attr {
+			continue
+		}
+
+		fv := v.Field(f.index)
+
+		if f.prefix == "xmlns" {
+			fv.SetString(namespaces[f.name])
+			continue
+		}
+
+		value, ok := object[f.name]
+		if !ok {
+			continue
+		}
+
+		if err := decodeJSONValue(value, fv, path+"."+f.name); err != nil {
+			return err
+		}
+
+		if fv.Type() == typeCurrency {
+			c := fv.Interface().(money.Currency)
+			currency = &c
+		}
+	}
+
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		fpath := path + "." + f.name
+
+		switch {
+		case f.attr:
+			continue
+		case f.chardata:
+			value, ok := object["_"]
+			if !ok {
+				continue
+			}
+
+			if fv.Type() == typeAmount {
+				if err := decodeJSONAmount(value, fv, currency, path); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := decodeJSONValue(value, fv, path); err != nil {
+				return err
+			}
+		default:
+			elements, err := jsonElements(object, f.name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			if len(elements) == 0 {
+				continue
+			}
+
+			switch fv.Kind() {
+			case reflect.Slice:
+				slice := reflect.MakeSlice(fv.Type(), len(elements), len(elements))
+				for i, element := range elements {
+					if err := decodeJSONOccurrence(element, slice.Index(i), fmt.Sprintf("%s[%d]", fpath, i)); err != nil {
+						return err
+					}
+				}
+
+				fv.Set(slice)
+			default:
+				if err := decodeJSONOccurrence(elements[0], fv, fpath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Decode into v, allocating it if it is a pointer.
+func decodeJSONOccurrence(object map[string]any, v reflect.Value, path string) error {
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	return decodeJSONElement(object, v, path)
+}
+
+// Decode the value of a basic element or attribute. Numbers and strings are
+// accepted for either kind of field.
+func decodeJSONValue(value any, v reflect.Value, path string) error {
+	var s string
+
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		s = value
+	case json.Number:
+		s = value.String()
+	case bool:
+		s = strconv.FormatBool(value)
+	default:
+		return fmt.Errorf("%s: unexpected %T", path, value)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetBool(b)
+	case reflect.Struct:
+		if v.Type() != typeCurrency {
+			return fmt.Errorf("%s: unsupported value of type %s", path, v.Type())
+		}
+
+		// an empty code is only valid for zero amounts, see decodeJSONAmount
+		if s == "" {
+			v.Set(reflect.Zero(typeCurrency))
+			break
+		}
+
+		currency := money.GetCurrency(s)
+		if currency == nil {
+			return fmt.Errorf("%s: unknown currency %q", path, s)
+		}
+
+		v.Set(reflect.ValueOf(*currency))
+	default:
+		return fmt.Errorf("%s: unsupported value of type %s", path, v.Type())
+	}
+
+	return nil
+}
+
+// Counterpart of the amount formatting in encodeJSONElement. As in
+// CurrencyUnmarshaler, the amount must be exact in the minor unit of its
+// currency.
+func decodeJSONAmount(value any, v reflect.Value, currency *money.Currency, path string) error {
+	var s string
+	switch value := value.(type) {
+	case string:
+		s = value
+	case json.Number:
+		s = value.String()
+	default:
+		return fmt.Errorf("%s: unexpected %T", path, value)
+	}
+
+	// without a currency, only a zero amount is unambiguous
+	if currency == nil || currency.Code == "" {
+		if minor, err := parseMinorUnits(s, 0); err != nil || minor != 0 {
+			return fmt.Errorf("%s: amount without currencyID", path)
+		}
+
+		return nil
+	}
+
+	minor, err := parseMinorUnits(s, currency.Fraction)
+	if err != nil {
+		return fmt.Errorf("%s: invalid amount: %w", path, err)
+	}
+
+	v.SetInt(minor)
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package ubl_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+func newJSONTestInvoice() *ubl.UBL_Invoice {
+	note := "Thank you"
+	description := "Laptop"
+	percent := "6.00"
+
+	inv := ubl.NewInvoice()
+	inv.ID = "INV-001"
+	inv.IssueDate = "2024-07-23"
+	inv.InvoiceTypeCode = ubl.CBC_InvoiceTypeCode{Value: "01", ListVersionID: "1.1"}
+	inv.Note = &note
+	inv.DocumentCurrencyCode = "MYR"
+	inv.Currency = *myr
+	inv.AccountingSupplierParty.Party.PartyIdentification = []ubl.CAC_PartyIdentification{
+		{ID: ubl.CAC_PartyIdentification_ID{Value: "C1234567890", SchemeID: "TIN"}},
+		{ID: ubl.CAC_PartyIdentification_ID{Value: "202001234567", SchemeID: "BRN"}},
+	}
+	inv.TaxTotal = ubl.CAC_TaxTotal{
+		TaxAmount: ubl.CBC_TaxAmount{Value: 8763, CurrencyID: *myr},
+		TaxSubtotal: []ubl.CAC_TaxSubtotal{
+			{
+				TaxableAmount: ubl.CBC_TaxableAmount{Value: 146050, CurrencyID: *myr},
+				TaxAmount:     ubl.CBC_TaxAmount{Value: 8763, CurrencyID: *myr},
+				TaxCategory:   ubl.CAC_TaxCategory{ID: "01", Percent: "6", TaxScheme: ubl.CAC_TaxScheme{ID: "OTH"}},
+			},
+		},
+	}
+	inv.LegalMonetaryTotal.PayableAmount = ubl.CBC_PayableAmount{Value: 154813, CurrencyID: *myr}
+	inv.InvoiceLine = []ubl.CAC_InvoiceLine{
+		{
+			ID:                  "1",
+			InvoicedQuantity:    ubl.CBC_InvoicedQuantity{Value: "2", UnitCode: "C62"},
+			LineExtensionAmount: ubl.CBC_LineExtensionAmount{Value: 146050, CurrencyID: *myr},
+			Item: ubl.CAC_Item{
+				Description: &description,
+				Name:        "Laptop",
+				ClassifiedTaxCategory: []ubl.CAC_ClassifiedTaxCategory{
+					{ID: "01", Percent: &percent, TaxScheme: ubl.CAC_TaxScheme{ID: "OTH"}},
+				},
+			},
+			Price: ubl.CAC_Price{
+				PriceAmount:  ubl.CBC_PriceAmount{Value: 73025, CurrencyId: *myr},
+				BaseQuantity: 1,
+			},
+		},
+	}
+
+	return inv
+}
+
+func TestMarshalInvoiceJSON(t *testing.T) {
+	b, err := ubl.MarshalInvoiceJSON(newJSONTestInvoice())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !json.Valid(b) {
+		t.Fatalf("expected valid JSON, got %s", b)
+	}
+
+	expected := []string{
+		`{"_D":"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",` +
+			`"_A":"urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",` +
+			`"_B":"urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2","Invoice":[{`,
+		`"ID":[{"_":"INV-001"}]`,
+		`"InvoiceTypeCode":[{"_":"01","listVersionID":"1.1"}]`,
+		`"Note":[{"_":"Thank you"}]`,
+		`"PartyIdentification":[{"ID":[{"_":"C1234567890","schemeID":"TIN"}]},{"ID":[{"_":"202001234567","schemeID":"BRN"}]}]`,
+		`"TaxAmount":[{"_":87.63,"currencyID":"MYR"}]`,
+		`"Percent":[{"_":6}]`,
+		`"PayableAmount":[{"_":1548.13,"currencyID":"MYR"}]`,
+		`"InvoicedQuantity":[{"_":2,"unitCode":"C62"}]`,
+		`"PriceAmount":[{"_":730.25,"currencyID":"MYR"}],"BaseQuantity":[{"_":1}]`,
+		`"Percent":[{"_":6.00}]`,
+	}
+
+	for _, e := range expected {
+		if !bytes.Contains(b, []byte(e)) {
+			t.Errorf("expected JSON to contain %s, got %s", e, b)
+		}
+	}
+
+	unexpected := []string{`xmlns`, `"UBLExtensions"`, `"Signature"`, `"DueDate"`, `"XMLName"`}
+	for _, u := range unexpected {
+		if bytes.Contains(b, []byte(u)) {
+			t.Errorf("expected JSON not to contain %s, got %s", u, b)
+		}
+	}
+}
+
+func TestParseInvoiceJSONRoundTrip(t *testing.T) {
+	inv := newJSONTestInvoice()
+
+	b, err := ubl.MarshalInvoiceJSON(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parsed, err := ubl.ParseInvoiceJSON(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	again, err := ubl.MarshalInvoiceJSON(parsed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(b, again) {
+		t.Errorf("expected JSON to round trip\n%s\ngot\n%s", b, again)
+	}
+
+	expectedXML, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parsedXML, err := xml.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(expectedXML, parsedXML) {
+		t.Errorf("expected the same XML after a JSON round trip\n%s\ngot\n%s", expectedXML, parsedXML)
+	}
+
+	if parsed.Currency.Code != "MYR" {
+		t.Errorf("expected currency to be MYR, got %s", parsed.Currency.Code)
+	}
+}
+
+func TestInvoiceJSONAmountFractions(t *testing.T) {
+	inv := newJSONTestInvoice()
+	inv.TaxTotal.TaxAmount = ubl.CBC_TaxAmount{Value: 1460, CurrencyID: *money.GetCurrency(money.JPY)}
+	inv.LegalMonetaryTotal.PayableAmount = ubl.CBC_PayableAmount{Value: 1234, CurrencyID: *money.GetCurrency(money.KWD)}
+	inv.LegalMonetaryTotal.PrepaidAmount = &ubl.CBC_PrepaidAmount{Value: -5, CurrencyID: *money.GetCurrency(money.BHD)}
+
+	b, err := ubl.MarshalInvoiceJSON(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		`"TaxAmount":[{"_":1460,"currencyID":"JPY"}]`,
+		`"PayableAmount":[{"_":1.234,"currencyID":"KWD"}]`,
+		`"PrepaidAmount":[{"_":-0.005,"currencyID":"BHD"}]`,
+	}
+
+	for _, e := range expected {
+		if !bytes.Contains(b, []byte(e)) {
+			t.Errorf("expected JSON to contain %s, got %s", e, b)
+		}
+	}
+
+	parsed, err := ubl.ParseInvoiceJSON(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if parsed.TaxTotal.TaxAmount.Value != 1460 {
+		t.Errorf("expected tax amount to be 1460, got %d", parsed.TaxTotal.TaxAmount.Value)
+	}
+
+	if parsed.LegalMonetaryTotal.PayableAmount.Value != 1234 {
+		t.Errorf("expected payable amount to be 1234, got %d", parsed.LegalMonetaryTotal.PayableAmount.Value)
+	}
+
+	if parsed.LegalMonetaryTotal.PrepaidAmount == nil || parsed.LegalMonetaryTotal.PrepaidAmount.Value != -5 {
+		t.Errorf("expected prepaid amount to be -5, got %+v", parsed.LegalMonetaryTotal.PrepaidAmount)
+	}
+}
+
+func TestParseInvoiceJSON(t *testing.T) {
+	// as in the platform samples, with whitespace and string amounts
+	doc := `{
+		"_D": "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		"_A": "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		"_B": "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		"Invoice": [{
+			"ID": [{"_": "XML-INV12345"}],
+			"IssueDate": [{"_": "2024-07-23"}],
+			"InvoiceTypeCode": [{"_": "01", "listVersionID": "1.1"}],
+			"DocumentCurrencyCode": [{"_": "MYR"}],
+			"UBLExtensions": [{"UBLExtension": [{
+				"ExtensionURI": [{"_": "urn:oasis:names:specification:ubl:dsig:enveloped:xades"}],
+				"ExtensionContent": [{"UBLDocumentSignatures": [{"SignatureInformation": [{
+					"ID": [{"_": "urn:oasis:names:specification:ubl:signature:1"}],
+					"ReferencedSignatureID": [{"_": "urn:oasis:names:specification:ubl:signature:Invoice"}],
+					"Signature": [{"Id": "signature", "SignatureValue": [{"_": "c2lnbmF0dXJl"}]}]
+				}]}]}]
+			}]}],
+			"TaxTotal": [{"TaxAmount": [{"_": "87.63", "currencyID": "MYR"}]}],
+			"InvoiceLine": [
+				{"ID": [{"_": "1"}], "InvoicedQuantity": [{"_": 1, "unitCode": "C62"}], "Price": [{"PriceAmount": [{"_": 17, "currencyID": "MYR"}]}]},
+				{"ID": [{"_": "2"}], "InvoicedQuantity": [{"_": 2.5, "unitCode": "KGM"}]}
+			]
+		}]
+	}`
+
+	inv, err := ubl.ParseInvoiceJSON([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.ID != "XML-INV12345" {
+		t.Errorf("expected ID to be XML-INV12345, got %s", inv.ID)
+	}
+
+	if inv.InvoiceTypeCode.ListVersionID != "1.1" {
+		t.Errorf("expected listVersionID to be 1.1, got %s", inv.InvoiceTypeCode.ListVersionID)
+	}
+
+	if inv.TaxTotal.TaxAmount.Value != 8763 || inv.TaxTotal.TaxAmount.CurrencyID.Code != "MYR" {
+		t.Errorf("expected tax amount to be 8763 MYR cents, got %d %s", inv.TaxTotal.TaxAmount.Value, inv.TaxTotal.TaxAmount.CurrencyID.Code)
+	}
+
+	if len(inv.InvoiceLine) != 2 {
+		t.Fatalf("expected 2 invoice lines, got %d", len(inv.InvoiceLine))
+	}
+
+	if inv.InvoiceLine[0].Price.PriceAmount.Value != 1700 {
+		t.Errorf("expected price amount to be 1700, got %d", inv.InvoiceLine[0].Price.PriceAmount.Value)
+	}
+
+	if q := inv.InvoiceLine[1].InvoicedQuantity; q.Value != "2.5" || q.UnitCode != "KGM" {
+		t.Errorf("expected quantity to be 2.5 KGM, got %s %s", q.Value, q.UnitCode)
+	}
+
+	info := inv.DocumentSignature()
+	if info == nil {
+		t.Fatalf("expected signature in UBLExtensions")
+	}
+
+	if info.Signature.ID != "signature" || info.Signature.SignatureValue != "c2lnbmF0dXJl" {
+		t.Errorf("expected signature to be parsed, got %+v", info.Signature)
+	}
+
+	signatures := inv.UBLExtensions.UBLExtension[0].ExtensionContent.UBLDocumentSignatures
+	if signatures.SIGEnv != ubl.SIGNATURE_NAMESPACE_SIG {
+		t.Errorf("expected the sig namespace to be filled in, got %q", signatures.SIGEnv)
+	}
+}
+
+func TestParseInvoiceJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{"not JSON", `<Invoice/>`, "invalid character"},
+		{"no invoice", `{"_D":"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"}`, "no Invoice"},
+		{"not wrapped", `{"Invoice":[{"ID":{"_":"INV-001"}}]}`, "ID is not an array"},
+		{"amount without currency", `{"Invoice":[{"TaxTotal":[{"TaxAmount":[{"_":1}]}]}]}`, "amount without currencyID"},
+		{"invalid amount", `{"Invoice":[{"TaxTotal":[{"TaxAmount":[{"_":"one","currencyID":"MYR"}]}]}]}`, "invalid amount"},
+		{"too many decimal places", `{"Invoice":[{"TaxTotal":[{"TaxAmount":[{"_":1.005,"currencyID":"MYR"}]}]}]}`, "more than 2 decimal places"},
+		{"amount out of range", `{"Invoice":[{"TaxTotal":[{"TaxAmount":[{"_":92233720368547758.08,"currencyID":"MYR"}]}]}]}`, "out of range"},
+		{"unknown currency", `{"Invoice":[{"TaxTotal":[{"TaxAmount":[{"_":12.34,"currencyID":"XYZ"}]}]}]}`, "unknown currency"},
+		{"unknown document currency", `{"Invoice":[{"DocumentCurrencyCode":[{"_":"XYZ"}]}]}`, `unknown currency "XYZ"`},
+		{"unknown tax currency", `{"Invoice":[{"TaxCurrencyCode":[{"_":"XYZ"}]}]}`, `unknown currency "XYZ"`},
+	}
+
+	for _, test := range tests {
+		_, err := ubl.ParseInvoiceJSON([]byte(test.doc))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected %s to fail with %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestParseInvoiceJSONAmountsExact(t *testing.T) {
+	doc := `{"Invoice":[{"LegalMonetaryTotal":[{"PayableAmount":[{"_":90071992547409.93,"currencyID":"MYR"}]}]}]}`
+
+	inv, err := ubl.ParseInvoiceJSON([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.LegalMonetaryTotal.PayableAmount.Value != 9007199254740993 {
+		t.Errorf("expected payable amount to be 9007199254740993, got %d", inv.LegalMonetaryTotal.PayableAmount.Value)
+	}
+}
@@ -0,0 +1,150 @@
+package ubl
+
+// Enveloped XAdES signature, as required for v1.1 documents.
+// https://sdk.myinvois.hasil.gov.my/signature/
+
+const (
+	SIGNATURE_ID               = "urn:oasis:names:specification:ubl:signature:Invoice"
+	SIGNATURE_INFORMATION_ID   = "urn:oasis:names:specification:ubl:signature:1"
+	SIGNATURE_METHOD           = "urn:oasis:names:specification:ubl:dsig:enveloped:xades"
+	SIGNATURE_EXTENSION_URI    = SIGNATURE_METHOD
+	SIGNATURE_NAMESPACE_SIG    = "urn:oasis:names:specification:ubl:schema:xsd:CommonSignatureComponents-2"
+	SIGNATURE_NAMESPACE_SAC    = "urn:oasis:names:specification:ubl:schema:xsd:SignatureAggregateComponents-2"
+	SIGNATURE_NAMESPACE_SBC    = "urn:oasis:names:specification:ubl:schema:xsd:SignatureBasicComponents-2"
+	SIGNATURE_NAMESPACE_DS     = "http://www.w3.org/2000/09/xmldsig#"
+	SIGNATURE_NAMESPACE_XADES  = "http://uri.etsi.org/01903/v1.3.2#"
+	SIGNATURE_SIGNED_PROPS_ID  = "id-xades-signed-props"
+	SIGNATURE_DOC_REFERENCE_ID = "id-doc-signed-data"
+	SIGNATURE_ELEMENT_ID       = "signature"
+)
+
+type EXT_UBLExtensions struct {
+	UBLExtension []EXT_UBLExtension `xml:"ext:UBLExtension"` // [1..n]
+}
+
+type EXT_UBLExtension struct {
+	ExtensionURI     string               `xml:"ext:ExtensionURI"`     // [1..1] SIGNATURE_EXTENSION_URI for the signature
+	ExtensionContent EXT_ExtensionContent `xml:"ext:ExtensionContent"` // [1..1]
+}
+
+type EXT_ExtensionContent struct {
+	UBLDocumentSignatures *SIG_UBLDocumentSignatures `xml:"sig:UBLDocumentSignatures,omitempty"` // [0..1]
+}
+
+type SIG_UBLDocumentSignatures struct {
+	SIGEnv               string                   `xml:"xmlns:sig,attr"`
+	SACEnv               string                   `xml:"xmlns:sac,attr"`
+	SBCEnv               string                   `xml:"xmlns:sbc,attr"`
+	SignatureInformation SAC_SignatureInformation `xml:"sac:SignatureInformation"` // [1..1]
+}
+
+type SAC_SignatureInformation struct {
+	ID                    string       `xml:"cbc:ID"`                    // [1..1] SIGNATURE_INFORMATION_ID
+	ReferencedSignatureID string       `xml:"sbc:ReferencedSignatureID"` // [1..1] SIGNATURE_ID, the ID of cac:Signature
+	Signature             DS_Signature `xml:"ds:Signature"`              // [1..1]
+}
+
+type DS_Signature struct {
+	DSEnv          string        `xml:"xmlns:ds,attr"`
+	ID             string        `xml:"Id,attr"`
+	SignedInfo     DS_SignedInfo `xml:"ds:SignedInfo"`     // [1..1]
+	SignatureValue string        `xml:"ds:SignatureValue"` // [1..1] base64
+	KeyInfo        DS_KeyInfo    `xml:"ds:KeyInfo"`        // [1..1]
+	Object         DS_Object     `xml:"ds:Object"`         // [1..1]
+}
+
+type DS_SignedInfo struct {
+	CanonicalizationMethod DS_Algorithm   `xml:"ds:CanonicalizationMethod"` // [1..1]
+	SignatureMethod        DS_Algorithm   `xml:"ds:SignatureMethod"`        // [1..1]
+	Reference              []DS_Reference `xml:"ds:Reference"`              // [2..2] the document and the signed properties
+}
+
+type DS_Algorithm struct {
+	Algorithm string `xml:"Algorithm,attr"`
+}
+
+type DS_Reference struct {
+	ID           string         `xml:"Id,attr,omitempty"`
+	Type         string         `xml:"Type,attr,omitempty"`
+	URI          string         `xml:"URI,attr"`
+	Transforms   *DS_Transforms `xml:"ds:Transforms,omitempty"` // [0..1]
+	DigestMethod DS_Algorithm   `xml:"ds:DigestMethod"`         // [1..1]
+	DigestValue  string         `xml:"ds:DigestValue"`          // [1..1] base64
+}
+
+type DS_Transforms struct {
+	Transform []DS_Transform `xml:"ds:Transform"` // [1..n]
+}
+
+type DS_Transform struct {
+	Algorithm string  `xml:"Algorithm,attr"`
+	XPath     *string `xml:"ds:XPath,omitempty"` // [0..1]
+}
+
+type DS_KeyInfo struct {
+	X509Data DS_X509Data `xml:"ds:X509Data"` // [1..1]
+}
+
+type DS_X509Data struct {
+	X509Certificate []string `xml:"ds:X509Certificate"` // [1..n] base64 DER, signing certificate first
+}
+
+type DS_Object struct {
+	QualifyingProperties XADES_QualifyingProperties `xml:"xades:QualifyingProperties"` // [1..1]
+}
+
+type XADES_QualifyingProperties struct {
+	XADESEnv         string                 `xml:"xmlns:xades,attr"`
+	Target           string                 `xml:"Target,attr"`            // SIGNATURE_ELEMENT_ID
+	SignedProperties XADES_SignedProperties `xml:"xades:SignedProperties"` // [1..1]
+}
+
+type XADES_SignedProperties struct {
+	ID                        string                          `xml:"Id,attr"`                         // SIGNATURE_SIGNED_PROPS_ID
+	SignedSignatureProperties XADES_SignedSignatureProperties `xml:"xades:SignedSignatureProperties"` // [1..1]
+}
+
+type XADES_SignedSignatureProperties struct {
+	SigningTime        string                   `xml:"xades:SigningTime"`        // [1..1] UTC, e.g. 2024-07-23T15:14:54Z
+	SigningCertificate XADES_SigningCertificate `xml:"xades:SigningCertificate"` // [1..1]
+}
+
+type XADES_SigningCertificate struct {
+	Cert XADES_Cert `xml:"xades:Cert"` // [1..1]
+}
+
+type XADES_Cert struct {
+	CertDigest   XADES_CertDigest   `xml:"xades:CertDigest"`   // [1..1]
+	IssuerSerial XADES_IssuerSerial `xml:"xades:IssuerSerial"` // [1..1]
+}
+
+type XADES_CertDigest struct {
+	DigestMethod DS_Algorithm `xml:"ds:DigestMethod"` // [1..1]
+	DigestValue  string       `xml:"ds:DigestValue"`  // [1..1] base64
+}
+
+type XADES_IssuerSerial struct {
+	X509IssuerName   string `xml:"ds:X509IssuerName"`   // [1..1]
+	X509SerialNumber string `xml:"ds:X509SerialNumber"` // [1..1] decimal
+}
+
+type CAC_Signature struct {
+	ID              string `xml:"cbc:ID"`              // [1..1] SIGNATURE_ID
+	SignatureMethod string `xml:"cbc:SignatureMethod"` // [1..1] SIGNATURE_METHOD
+}
+
+// The signature in the document extensions, or nil if the document is not
+// signed.
+func (i *UBL_Invoice) DocumentSignature() *SAC_SignatureInformation {
+	if i.UBLExtensions == nil {
+		return nil
+	}
+
+	for _, ext := range i.UBLExtensions.UBLExtension {
+		if ext.ExtensionURI == SIGNATURE_EXTENSION_URI && ext.ExtensionContent.UBLDocumentSignatures != nil {
+			return &ext.ExtensionContent.UBLDocumentSignatures.SignatureInformation
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package ubl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
+
+// ParseInvoice parses an XML UBL invoice, such as the raw document returned
+// by the platform or an invoice from other software, into a UBL_Invoice.
+// Elements are matched by namespace rather than by prefix, so the document
+// may use any prefixes or a default namespace.
+func ParseInvoice(data []byte) (*UBL_Invoice, error) {
+	inv := NewInvoice()
+	if err := newDecoder(data).Decode(inv); err != nil {
+		return nil, fmt.Errorf("failed to parse invoice: %w", err)
+	}
+
+	fillNamespaces(reflect.ValueOf(inv))
+
+	if inv.DocumentCurrencyCode != "" {
+		currency := money.GetCurrency(inv.DocumentCurrencyCode)
+		if currency == nil {
+			return nil, fmt.Errorf("failed to parse invoice: unknown currency %q in cbc:DocumentCurrencyCode", inv.DocumentCurrencyCode)
+		}
+
+		inv.Currency = *currency
+	}
+
+	return inv, nil
+}
+
+// Prefixes of the struct tags in this package by namespace URI. Elements in
+// the namespace of the invoice itself are unprefixed, as in UBL_Invoice.
+var prefixes = func() map[string]string {
+	m := make(map[string]string, len(namespaces))
+	for prefix, uri := range namespaces {
+		m[uri] = prefix
+	}
+	m[namespaces["ubl"]] = ""
+
+	return m
+}()
+
+func newDecoder(data []byte) *xml.Decoder {
+	return xml.NewTokenDecoder(&namespaceTokenReader{d: xml.NewDecoder(bytes.NewReader(data))})
+}
+
+// namespaceTokenReader resolves the namespace of every element and attribute
+// and names it with the prefix used in the struct tags of this package, e.g.
+// "cbc:ID", whatever prefix the document declared. Namespace declarations
+// are dropped; fillNamespaces sets them afterwards.
+type namespaceTokenReader struct {
+	d *xml.Decoder
+}
+
+func (r *namespaceTokenReader) Token() (xml.Token, error) {
+	tok, err := r.d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		t.Name = prefixedName(t.Name)
+
+		attrs := make([]xml.Attr, 0, len(t.Attr))
+		for _, attr := range t.Attr {
+			if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+				continue
+			}
+
+			attrs = append(attrs, xml.Attr{Name: prefixedName(attr.Name), Value: attr.Value})
+		}
+		t.Attr = attrs
+
+		return t, nil
+	case xml.EndElement:
+		t.Name = prefixedName(t.Name)
+		return t, nil
+	}
+
+	return xml.CopyToken(tok), nil
+}
+
+// Names in unknown namespaces are left as they are and so match no field.
+// An undeclared prefix is left in Space by the decoder, and is accepted if
+// it is one of ours.
+func prefixedName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+
+	prefix, ok := prefixes[name.Space]
+	if !ok {
+		if _, ok := namespaces[name.Space]; !ok {
+			return name
+		}
+
+		prefix = name.Space
+	}
+
+	if prefix == "" {
+		return xml.Name{Local: name.Local}
+	}
+
+	return xml.Name{Local: prefix + ":" + name.Local}
+}
+
+// Set the namespace declarations of v, and of the elements within, to the
+// namespaces of this package.
+func fillNamespaces(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			fillNamespaces(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			fillNamespaces(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == typeCurrency {
+			return
+		}
+
+		for _, f := range xmlFields(v.Type()) {
+			fv := v.Field(f.index)
+
+			switch {
+			case f.attr && f.prefix == "xmlns":
+				fv.SetString(namespaces[f.name])
+			case !f.attr && !f.chardata:
+				fillNamespaces(fv)
+			}
+		}
+	}
+}
+
+// Counterpart of CurrencyMarshaler. The amount must be exact in the minor
+// unit of its currency, it is never rounded.
+func CurrencyUnmarshaler(d *xml.Decoder, s xml.StartElement) (*money.Money, error) {
+	toDecode := struct {
+		Amount     string `xml:",chardata"`
+		CurrencyID string `xml:"currencyID,attr"`
+	}{}
+
+	if err := d.DecodeElement(&toDecode, &s); err != nil {
+		return nil, err
+	}
+
+	// without a currency, only a zero amount is unambiguous
+	if toDecode.CurrencyID == "" {
+		if minor, err := parseMinorUnits(toDecode.Amount, 0); err != nil || minor != 0 {
+			return nil, fmt.Errorf("amount without currencyID in %s", s.Name.Local)
+		}
+
+		return money.New(0, ""), nil
+	}
+
+	currency := money.GetCurrency(toDecode.CurrencyID)
+	if currency == nil {
+		return nil, fmt.Errorf("unknown currency %q in %s", toDecode.CurrencyID, s.Name.Local)
+	}
+
+	minor, err := parseMinorUnits(toDecode.Amount, currency.Fraction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount in %s: %w", s.Name.Local, err)
+	}
+
+	return money.New(minor, currency.Code), nil
+}
+
+// Parse a decimal amount exactly into minor units of a currency with
+// fraction decimal places. Further decimal places must be zero.
+func parseMinorUnits(amount string, fraction int) (int64, error) {
+	amount = strings.TrimSpace(amount)
+
+	sign, digits := "", amount
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%q is not a decimal number", amount)
+	}
+
+	if len(strings.TrimRight(frac, "0")) > fraction {
+		return 0, fmt.Errorf("%s has more than %d decimal places", amount, fraction)
+	}
+
+	if len(frac) > fraction {
+		frac = frac[:fraction]
+	} else {
+		frac += strings.Repeat("0", fraction-len(frac))
+	}
+
+	minor, err := strconv.ParseInt(sign+"0"+whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is out of range", amount)
+	}
+
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Counterpart of the MarshalXML of the currency code elements, e.g.
+// CBC_TaxCurrencyCode.
+func CurrencyCodeUnmarshaler(d *xml.Decoder, s xml.StartElement) (*money.Currency, error) {
+	var code string
+	if err := d.DecodeElement(&code, &s); err != nil {
+		return nil, err
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("missing currency code in %s", s.Name.Local)
+	}
+
+	currency := money.GetCurrency(code)
+	if currency == nil {
+		return nil, fmt.Errorf("unknown currency %q in %s", code, s.Name.Local)
+	}
+
+	return currency, nil
+}
+
+// ParseSignature parses only the signature in the extensions of an XML UBL
+// document, returning nil if the document is not signed. Unlike
+// ParseInvoice, the rest of the document is not decoded.
+func ParseSignature(data []byte) (*SAC_SignatureInformation, error) {
+	var doc struct {
+		UBLExtensions *EXT_UBLExtensions `xml:"ext:UBLExtensions"`
+	}
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	fillNamespaces(reflect.ValueOf(doc.UBLExtensions))
+
+	inv := UBL_Invoice{UBLExtensions: doc.UBLExtensions}
+
+	return inv.DocumentSignature(), nil
+}
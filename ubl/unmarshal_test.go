@@ -0,0 +1,282 @@
+package ubl_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/programmer-my/einvoice-go/ubl"
+)
+
+func TestParseInvoiceRoundTrip(t *testing.T) {
+	inv := newJSONTestInvoice()
+	inv.TaxCurrencyCode = &ubl.CBC_TaxCurrencyCode{Code: *money.GetCurrency(money.USD)}
+	inv.AccountingSupplierParty.Party.PartyIdentification[0].ID.Value = "C1234567890 & Sons"
+
+	b, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parsed, err := ubl.ParseInvoice(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	again, err := xml.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(b, again) {
+		t.Errorf("expected XML to round trip\n%s\ngot\n%s", b, again)
+	}
+
+	if parsed.Currency.Code != "MYR" {
+		t.Errorf("expected currency to be MYR, got %s", parsed.Currency.Code)
+	}
+}
+
+func TestParseInvoiceNamespaces(t *testing.T) {
+	// a default namespace for the invoice and the basic components, and
+	// prefixes of other software for the rest
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	xmlns:a="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	xmlns:ns3="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+	xmlns:x="urn:example:other">
+	<ns3:UBLExtensions>
+		<ns3:UBLExtension>
+			<ns3:ExtensionURI>urn:oasis:names:specification:ubl:dsig:enveloped:xades</ns3:ExtensionURI>
+			<ns3:ExtensionContent>
+				<s:UBLDocumentSignatures xmlns:s="urn:oasis:names:specification:ubl:schema:xsd:CommonSignatureComponents-2"
+					xmlns:sa="urn:oasis:names:specification:ubl:schema:xsd:SignatureAggregateComponents-2"
+					xmlns:sb="urn:oasis:names:specification:ubl:schema:xsd:SignatureBasicComponents-2">
+					<sa:SignatureInformation>
+						<ID xmlns="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">urn:oasis:names:specification:ubl:signature:1</ID>
+						<sb:ReferencedSignatureID>urn:oasis:names:specification:ubl:signature:Invoice</sb:ReferencedSignatureID>
+						<Signature xmlns="http://www.w3.org/2000/09/xmldsig#" Id="signature">
+							<SignatureValue>c2lnbmF0dXJl</SignatureValue>
+						</Signature>
+					</sa:SignatureInformation>
+				</s:UBLDocumentSignatures>
+			</ns3:ExtensionContent>
+		</ns3:UBLExtension>
+	</ns3:UBLExtensions>
+	<b:ID xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">INV-001</b:ID>
+	<x:ID>ignored</x:ID>
+	<b:DocumentCurrencyCode xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">MYR</b:DocumentCurrencyCode>
+	<b:TaxCurrencyCode xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"> USD </b:TaxCurrencyCode>
+	<a:AccountingSupplierParty xmlns="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+		<a:Party>
+			<EndpointID schemeID="TIN">C1234567890</EndpointID>
+			<a:PartyIdentification>
+				<ID schemeID="TIN">C1234567890 &amp; Sons</ID>
+			</a:PartyIdentification>
+		</a:Party>
+	</a:AccountingSupplierParty>
+	<a:TaxTotal xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+		<b:TaxAmount currencyID="MYR">87.63</b:TaxAmount>
+	</a:TaxTotal>
+	<a:LegalMonetaryTotal xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+		<b:PayableAmount currencyID="MYR"> 1548.130 </b:PayableAmount>
+	</a:LegalMonetaryTotal>
+	<a:InvoiceLine xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+		<b:ID>1</b:ID>
+		<b:InvoicedQuantity unitCode="KGM">2.5</b:InvoicedQuantity>
+		<b:LineExtensionAmount currencyID="JPY">1460</b:LineExtensionAmount>
+	</a:InvoiceLine>
+</Invoice>`
+
+	inv, err := ubl.ParseInvoice([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inv.ID != "INV-001" {
+		t.Errorf("expected ID to be INV-001, got %s", inv.ID)
+	}
+
+	if inv.Currency.Code != "MYR" {
+		t.Errorf("expected currency to be MYR, got %s", inv.Currency.Code)
+	}
+
+	if inv.TaxCurrencyCode == nil || inv.TaxCurrencyCode.Code.Code != "USD" {
+		t.Errorf("expected tax currency to be USD, got %+v", inv.TaxCurrencyCode)
+	}
+
+	party := inv.AccountingSupplierParty.Party
+	if party.EndpointID.Value != "C1234567890" || party.EndpointID.SchemeID != "TIN" {
+		t.Errorf("expected endpoint to be TIN C1234567890, got %+v", party.EndpointID)
+	}
+
+	if len(party.PartyIdentification) != 1 || party.PartyIdentification[0].ID.Value != "C1234567890 & Sons" {
+		t.Errorf("expected party identification to be C1234567890 & Sons, got %+v", party.PartyIdentification)
+	}
+
+	if inv.TaxTotal.TaxAmount.Value != 8763 || inv.TaxTotal.TaxAmount.CurrencyID.Code != "MYR" {
+		t.Errorf("expected tax amount to be 8763 MYR cents, got %d %s", inv.TaxTotal.TaxAmount.Value, inv.TaxTotal.TaxAmount.CurrencyID.Code)
+	}
+
+	if inv.LegalMonetaryTotal.PayableAmount.Value != 154813 {
+		t.Errorf("expected payable amount to be 154813, got %d", inv.LegalMonetaryTotal.PayableAmount.Value)
+	}
+
+	if len(inv.InvoiceLine) != 1 {
+		t.Fatalf("expected 1 invoice line, got %d", len(inv.InvoiceLine))
+	}
+
+	line := inv.InvoiceLine[0]
+	if line.InvoicedQuantity.Value != "2.5" || line.InvoicedQuantity.UnitCode != "KGM" {
+		t.Errorf("expected quantity to be 2.5 KGM, got %s %s", line.InvoicedQuantity.Value, line.InvoicedQuantity.UnitCode)
+	}
+
+	if line.LineExtensionAmount.Value != 1460 || line.LineExtensionAmount.CurrencyID.Code != "JPY" {
+		t.Errorf("expected line extension amount to be 1460 JPY, got %d %s", line.LineExtensionAmount.Value, line.LineExtensionAmount.CurrencyID.Code)
+	}
+
+	info := inv.DocumentSignature()
+	if info == nil {
+		t.Fatalf("expected signature in UBLExtensions")
+	}
+
+	if info.ReferencedSignatureID != ubl.SIGNATURE_ID || info.Signature.ID != "signature" || info.Signature.SignatureValue != "c2lnbmF0dXJl" {
+		t.Errorf("expected signature to be parsed, got %+v", info)
+	}
+
+	if info.Signature.DSEnv != ubl.SIGNATURE_NAMESPACE_DS {
+		t.Errorf("expected the ds namespace to be filled in, got %q", info.Signature.DSEnv)
+	}
+
+	if inv.CBCEnv != "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2" {
+		t.Errorf("expected the cbc namespace to be declared, got %q", inv.CBCEnv)
+	}
+
+	b, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, e := range []string{`<cbc:ID>INV-001</cbc:ID>`, `<cbc:TaxCurrencyCode>USD</cbc:TaxCurrencyCode>`, `<cbc:ID schemeID="TIN">C1234567890 &amp; Sons</cbc:ID>`} {
+		if !bytes.Contains(b, []byte(e)) {
+			t.Errorf("expected XML to contain %s, got %s", e, b)
+		}
+	}
+}
+
+func TestUnmarshalAmounts(t *testing.T) {
+	amounts := []interface {
+		xml.Marshaler
+		xml.Unmarshaler
+	}{
+		&ubl.CBC_Amount{},
+		&ubl.CBC_TaxAmount{},
+		&ubl.CBC_TaxableAmount{},
+		&ubl.CBC_LineExtensionAmount{},
+		&ubl.CBC_TaxExclusiveAmount{},
+		&ubl.CBC_TaxInclusiveAmount{},
+		&ubl.CBC_AllowanceTotalAmount{},
+		&ubl.CBC_ChargeTotalAmount{},
+		&ubl.CBC_PrepaidAmount{},
+		&ubl.CBC_PayableRoundingAmount{},
+		&ubl.CBC_PayableAmount{},
+		&ubl.CBC_PriceAmount{},
+	}
+
+	for _, amount := range amounts {
+		if err := xml.Unmarshal([]byte(`<Amount currencyID="MYR">1234.56</Amount>`), amount); err != nil {
+			t.Errorf("unexpected error for %T: %s", amount, err)
+			continue
+		}
+
+		b, err := xml.Marshal(amount)
+		if err != nil {
+			t.Errorf("unexpected error for %T: %s", amount, err)
+			continue
+		}
+
+		if !strings.Contains(string(b), `currencyID="MYR">1234.56<`) {
+			t.Errorf("expected %T to round trip, got %s", amount, b)
+		}
+	}
+}
+
+func TestUnmarshalAmountsExact(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		expected int64
+	}{
+		{"90071992547409.93", "MYR", 9007199254740993},
+		{"-0.10", "MYR", -10},
+		{"12.", "MYR", 1200},
+		{"1460.00", "JPY", 1460},
+		{"1.234", "KWD", 1234},
+		{"0.00", "", 0},
+	}
+
+	for _, test := range tests {
+		var amount ubl.CBC_PayableAmount
+		doc := `<Amount currencyID="` + test.currency + `">` + test.amount + `</Amount>`
+
+		if err := xml.Unmarshal([]byte(doc), &amount); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.amount, err)
+			continue
+		}
+
+		if amount.Value != test.expected {
+			t.Errorf("expected %s %s to be %d, got %d", test.amount, test.currency, test.expected, amount.Value)
+		}
+	}
+
+	failures := []struct {
+		amount   string
+		currency string
+		err      string
+	}{
+		{"1.005", "MYR", "more than 2 decimal places"},
+		{"1460.5", "JPY", "more than 0 decimal places"},
+		{"92233720368547758.08", "MYR", "out of range"},
+		{"1e3", "MYR", "not a decimal number"},
+		{"12.34", "XYZ", "unknown currency"},
+		{"0.01", "", "amount without currencyID"},
+	}
+
+	for _, test := range failures {
+		var amount ubl.CBC_PayableAmount
+		doc := `<Amount currencyID="` + test.currency + `">` + test.amount + `</Amount>`
+
+		err := xml.Unmarshal([]byte(doc), &amount)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected %s %s to fail with %q, got %v", test.amount, test.currency, test.err, err)
+		}
+	}
+}
+
+func TestParseInvoiceErrors(t *testing.T) {
+	cbc := `xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"`
+	cac := `xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"`
+
+	tests := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{"not XML", `{"Invoice":[]}`, "EOF"},
+		{"credit note", `<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"/>`, "expected element type <Invoice>"},
+		{"amount without currency", `<Invoice ` + cac + ` ` + cbc + `><cac:TaxTotal><cbc:TaxAmount>1.00</cbc:TaxAmount></cac:TaxTotal></Invoice>`, "amount without currencyID"},
+		{"invalid amount", `<Invoice ` + cac + ` ` + cbc + `><cac:TaxTotal><cbc:TaxAmount currencyID="MYR">one</cbc:TaxAmount></cac:TaxTotal></Invoice>`, "invalid amount"},
+		{"empty tax currency", `<Invoice ` + cbc + `><cbc:TaxCurrencyCode/></Invoice>`, "missing currency code"},
+		{"unknown document currency", `<Invoice ` + cbc + `><cbc:DocumentCurrencyCode>XYZ</cbc:DocumentCurrencyCode></Invoice>`, `unknown currency "XYZ"`},
+		{"unknown tax currency", `<Invoice ` + cbc + `><cbc:TaxCurrencyCode>XYZ</cbc:TaxCurrencyCode></Invoice>`, `unknown currency "XYZ"`},
+	}
+
+	for _, test := range tests {
+		_, err := ubl.ParseInvoice([]byte(test.doc))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected %s to fail with %q, got %v", test.name, test.err, err)
+		}
+	}
+}